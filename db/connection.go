@@ -2,45 +2,234 @@ package db
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	_ "github.com/microsoft/go-mssqldb"
 	"log"
+	"sort"
+	"strings"
+	"time"
 )
 
+// DefaultCompany is the company name used when no companies are configured
+const DefaultCompany = "default"
+
+// maxRetryBackoff caps the wait between startup connection attempts
+const maxRetryBackoff = 30 * time.Second
+
+// ErrUnknownCompany is returned when a company has no configured connection
+var ErrUnknownCompany = errors.New("unknown company")
+
 type Config struct {
+	Name     string
 	Server   string
 	Port     int
 	User     string
 	Password string
 	Database string
+	Schema   string
+
+	// Connection pool settings, zero values keep the database/sql defaults
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// Startup retry: the first ping is retried ConnectRetries times, doubling RetryBackoff each time
+	ConnectRetries int
+	RetryBackoff   time.Duration
+}
+
+// Connection is an open database connection for one company / fiscal year
+type Connection struct {
+	Name   string
+	Config Config
+	DB     *sql.DB
+
+	health healthState
 }
 
+// DB is the connection of the default company
 var DB *sql.DB
 
-// InitDB initializes the database connection
+var (
+	connections    map[string]*Connection
+	defaultCompany string
+)
+
+// InitDB initializes a single database connection used as the default company
 func InitDB(config Config) error {
+	return InitDBs([]Config{config})
+}
+
+// InitDBs initializes one database connection per company
+// The first configuration is used as the default company
+func InitDBs(configs []Config) error {
+	if len(configs) == 0 {
+		return fmt.Errorf("no database configuration provided")
+	}
+
+	opened := make(map[string]*Connection)
+	for i, config := range configs {
+		if config.Name == "" {
+			config.Name = DefaultCompany
+		}
+		if config.Schema == "" {
+			config.Schema = "dbo"
+		}
+
+		name := normalizeCompany(config.Name)
+		if _, exists := opened[name]; exists {
+			closeConnections(opened)
+			return fmt.Errorf("duplicate company configuration: %s", config.Name)
+		}
+
+		if err := validateIdentifier(config.Database); err != nil {
+			closeConnections(opened)
+			return fmt.Errorf("invalid database name for company %s: %v", config.Name, err)
+		}
+		if err := validateIdentifier(config.Schema); err != nil {
+			closeConnections(opened)
+			return fmt.Errorf("invalid schema name for company %s: %v", config.Name, err)
+		}
+
+		conn, err := open(config)
+		if err != nil {
+			closeConnections(opened)
+			return fmt.Errorf("company %s: %v", config.Name, err)
+		}
+		opened[name] = conn
+
+		if i == 0 {
+			defaultCompany = name
+			DB = conn.DB
+		}
+	}
+
+	connections = opened
+	return nil
+}
+
+// open opens and tests the connection for a single configuration
+func open(config Config) (*Connection, error) {
 	connString := fmt.Sprintf("server=%s;user id=%s;password=%s;port=%d;database=%s",
 		config.Server, config.User, config.Password, config.Port, config.Database)
 
-	var err error
-	DB, err = sql.Open("sqlserver", connString)
+	sqlDB, err := sql.Open("sqlserver", connString)
 	if err != nil {
-		return fmt.Errorf("error opening database: %v", err)
+		return nil, fmt.Errorf("error opening database: %v", err)
 	}
 
-	// Test the connection
-	err = DB.Ping()
+	// Apply connection pool settings
+	if config.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(config.MaxOpenConns)
+	}
+	if config.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(config.MaxIdleConns)
+	}
+	if config.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(config.ConnMaxLifetime)
+	}
+	if config.ConnMaxIdleTime > 0 {
+		sqlDB.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+	}
+
+	conn := &Connection{Name: config.Name, Config: config, DB: sqlDB}
+
+	// Test the connection, retrying with exponential backoff
+	err = pingWithRetry(conn)
 	if err != nil {
-		return fmt.Errorf("error connecting to database: %v", err)
+		sqlDB.Close()
+		return nil, fmt.Errorf("error connecting to database: %v", err)
 	}
 
-	log.Println("Database connection established successfully")
-	return nil
+	log.Printf("Database connection established successfully (company: %s, database: %s)", config.Name, config.Database)
+	return conn, nil
 }
 
-// CloseDB closes the database connection
+// pingWithRetry pings the database until it answers or the configured retries are used up
+func pingWithRetry(conn *Connection) error {
+	backoff := conn.Config.RetryBackoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = conn.ping()
+		if err == nil || attempt >= conn.Config.ConnectRetries {
+			return err
+		}
+
+		log.Printf("Database connection failed (company: %s, attempt %d of %d): %v, retrying in %s",
+			conn.Name, attempt+1, conn.Config.ConnectRetries+1, err, backoff)
+		time.Sleep(backoff)
+
+		backoff *= 2
+		if backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
+}
+
+// Get returns the connection for a company, or the default company if name is empty
+func Get(name string) (*Connection, error) {
+	if name == "" {
+		name = defaultCompany
+	}
+
+	conn, exists := connections[normalizeCompany(name)]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s (available: %s)", ErrUnknownCompany, name, strings.Join(Companies(), ", "))
+	}
+	return conn, nil
+}
+
+// Companies returns the names of all configured companies
+func Companies() []string {
+	names := make([]string, 0, len(connections))
+	for _, conn := range connections {
+		names = append(names, conn.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Table returns the fully qualified, quoted name of a table in the company database
+func (c *Connection) Table(name string) string {
+	return quoteIdentifier(c.Config.Database) + "." + quoteIdentifier(c.Config.Schema) + "." + quoteIdentifier(name)
+}
+
+// CloseDB closes all database connections
 func CloseDB() {
-	if DB != nil {
-		DB.Close()
+	closeConnections(connections)
+}
+
+func closeConnections(conns map[string]*Connection) {
+	for _, conn := range conns {
+		if conn.DB != nil {
+			conn.DB.Close()
+		}
 	}
-}
\ No newline at end of file
+}
+
+func normalizeCompany(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// validateIdentifier checks that a database or schema name is safe to use in queries
+func validateIdentifier(name string) error {
+	if name == "" {
+		return fmt.Errorf("name is empty")
+	}
+	for _, r := range name {
+		if !(r == '_' || r == '-' || r == '$' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			return fmt.Errorf("name %q contains invalid character %q", name, r)
+		}
+	}
+	return nil
+}
+
+func quoteIdentifier(name string) string {
+	return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+}
@@ -0,0 +1,147 @@
+package db
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// pingTimeout limits a single health ping
+const pingTimeout = 5 * time.Second
+
+// healthState holds the result of the latest pings of a connection
+type healthState struct {
+	mu          sync.Mutex
+	healthy     bool
+	lastCheck   time.Time
+	lastError   string
+	lastErrorAt time.Time
+}
+
+// PoolStats is the JSON form of sql.DBStats
+type PoolStats struct {
+	MaxOpenConnections int    `json:"max-open-connections"`
+	OpenConnections    int    `json:"open-connections"`
+	InUse              int    `json:"in-use"`
+	Idle               int    `json:"idle"`
+	WaitCount          int64  `json:"wait-count"`
+	WaitDuration       string `json:"wait-duration"`
+	MaxIdleClosed      int64  `json:"max-idle-closed"`
+	MaxIdleTimeClosed  int64  `json:"max-idle-time-closed"`
+	MaxLifetimeClosed  int64  `json:"max-lifetime-closed"`
+}
+
+// ConnectionHealth reports the health and pool statistics of one company connection
+type ConnectionHealth struct {
+	Company     string     `json:"company"`
+	Database    string     `json:"database"`
+	Healthy     bool       `json:"healthy"`
+	LastCheck   time.Time  `json:"last-check"`
+	LastError   string     `json:"last-error,omitempty"`
+	LastErrorAt *time.Time `json:"last-error-at,omitempty"`
+	Stats       PoolStats  `json:"stats"`
+}
+
+// ping checks the connection and records the result
+func (c *Connection) ping() error {
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+
+	err := c.DB.PingContext(ctx)
+
+	c.health.mu.Lock()
+	defer c.health.mu.Unlock()
+
+	wasHealthy := c.health.healthy
+	c.health.lastCheck = time.Now()
+	if err != nil {
+		c.health.healthy = false
+		c.health.lastError = err.Error()
+		c.health.lastErrorAt = c.health.lastCheck
+		if wasHealthy {
+			log.Printf("Database connection lost (company: %s): %v", c.Name, err)
+		}
+	} else {
+		c.health.healthy = true
+		if !wasHealthy && !c.health.lastErrorAt.IsZero() {
+			log.Printf("Database connection recovered (company: %s)", c.Name)
+		}
+	}
+
+	return err
+}
+
+// Health returns the health of a connection without pinging it
+func (c *Connection) Health() ConnectionHealth {
+	c.health.mu.Lock()
+	defer c.health.mu.Unlock()
+
+	stats := c.DB.Stats()
+	health := ConnectionHealth{
+		Company:   c.Name,
+		Database:  c.Config.Database,
+		Healthy:   c.health.healthy,
+		LastCheck: c.health.lastCheck,
+		LastError: c.health.lastError,
+		Stats: PoolStats{
+			MaxOpenConnections: stats.MaxOpenConnections,
+			OpenConnections:    stats.OpenConnections,
+			InUse:              stats.InUse,
+			Idle:               stats.Idle,
+			WaitCount:          stats.WaitCount,
+			WaitDuration:       stats.WaitDuration.String(),
+			MaxIdleClosed:      stats.MaxIdleClosed,
+			MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+			MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+		},
+	}
+	if !c.health.lastErrorAt.IsZero() {
+		lastErrorAt := c.health.lastErrorAt
+		health.LastErrorAt = &lastErrorAt
+	}
+	return health
+}
+
+// Health returns the health of all company connections, sorted by company name
+func Health() []ConnectionHealth {
+	var results []ConnectionHealth
+	for _, conn := range connections {
+		results = append(results, conn.Health())
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Company < results[j].Company
+	})
+	return results
+}
+
+// StartHealthProbe pings all connections every interval until stop is called
+func StartHealthProbe(interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	var once sync.Once
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				for _, conn := range connections {
+					conn.ping()
+				}
+			}
+		}
+	}()
+
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// adminTokens maps the bearer tokens of the admin API to the name of their holder, set once at startup
+var adminTokens map[string]string
+
+// adminContextKey carries the name of the authenticated admin in the request context
+type adminContextKey struct{}
+
+// SetAdminTokens configures the tokens accepted by the admin API, e.g. "t0k3n1=li.wei,t0k3n2=ops"
+// The name is recorded as the author of the changes made with the token
+func SetAdminTokens(config string) error {
+	tokens := make(map[string]string)
+	for _, pair := range strings.Split(config, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		token, name, ok := strings.Cut(pair, "=")
+		token, name = strings.TrimSpace(token), strings.TrimSpace(name)
+		if !ok || token == "" || name == "" {
+			return fmt.Errorf("invalid admin token entry (expected token=name)")
+		}
+		if _, exists := tokens[token]; exists {
+			return fmt.Errorf("duplicate admin token for %s", name)
+		}
+		tokens[token] = name
+	}
+	adminTokens = tokens
+	return nil
+}
+
+// AdminTokensConfigured reports whether the admin API accepts any token
+func AdminTokensConfigured() bool {
+	return len(adminTokens) > 0
+}
+
+// adminIdentity returns the name of the holder of the bearer token of a request
+func adminIdentity(r *http.Request) (string, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	token = strings.TrimSpace(token)
+	if !ok || token == "" {
+		return "", false
+	}
+
+	// Compare against every token in constant time
+	name := ""
+	for candidate, holder := range adminTokens {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(token)) == 1 {
+			name = holder
+		}
+	}
+	return name, name != ""
+}
+
+// RequireAdmin rejects requests without a valid admin token (Authorization: Bearer <token>)
+// The name of the token holder is passed to the handler in the request context
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, ok := adminIdentity(r)
+		if !ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("WWW-Authenticate", `Bearer realm="resco-admin"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			message := "a valid admin token is required (Authorization: Bearer <token>)"
+			if !AdminTokensConfigured() {
+				message = "the admin API is disabled, no ADMIN_TOKENS are configured"
+			}
+			json.NewEncoder(w).Encode(ErrorResponse{Error: message})
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), adminContextKey{}, name)))
+	})
+}
@@ -3,7 +3,10 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"resco/db"
 	"resco/services"
+	"sort"
+	"strings"
 
 	"github.com/gorilla/mux"
 )
@@ -18,6 +21,32 @@ type SuccessResponse struct {
 	Message string      `json:"message"`
 }
 
+type PagedResponse struct {
+	Data       interface{} `json:"data"`
+	Count      int         `json:"count"`
+	Total      int         `json:"total"`
+	NextCursor string      `json:"next-cursor,omitempty"`
+	Message    string      `json:"message"`
+}
+
+// TranslateError is an item without translation in a translated BOM response
+// Languages lists the missing languages in /api/bomcombined responses
+type TranslateError struct {
+	Code      string   `json:"code"`
+	Name      string   `json:"name"`
+	Languages []string `json:"languages,omitempty"`
+}
+
+// getConnection returns the database connection for the company selected by the request
+// The company is read from the "company" query parameter or the X-Company header
+func getConnection(r *http.Request) (*db.Connection, error) {
+	company := r.URL.Query().Get("company")
+	if company == "" {
+		company = r.Header.Get("X-Company")
+	}
+	return db.Get(company)
+}
+
 // GetBOMByItemCode handles GET requests for BOM data by item code
 func GetBOMByItemCode(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -32,20 +61,37 @@ func GetBOMByItemCode(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Call the service to get BOM data
-	results, err := services.GetBOMByCodeParameterized(itemCode)
+	// Select the company database from ?company= or the X-Company header
+	conn, err := getConnection(r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// Parse filtering, sorting and pagination options
+	opts, err := services.ParseBOMQueryOptions(r.URL.Query())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
 		return
 	}
 
+	// Call the service to get BOM data
+	results, page, err := services.GetBOMByCodeFiltered(r.Context(), conn, itemCode, opts)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
 	// Return success response
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(SuccessResponse{
-		Data:    results,
-		Count:   len(results),
-		Message: "BOM data retrieved successfully",
+	json.NewEncoder(w).Encode(PagedResponse{
+		Data:       results,
+		Count:      len(results),
+		Total:      page.Total,
+		NextCursor: page.NextCursor,
+		Message:    "BOM data retrieved successfully",
 	})
 }
 
@@ -63,35 +109,59 @@ func GetBOMByItemCodeCN(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Call the service to get BOM data with Chinese translations and track failures
-	results, untranslatedCodes, err := services.GetBOMByCodeWithTranslationTracking(itemCode)
+	// Select the company database from ?company= or the X-Company header
+	conn, err := getConnection(r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	// Build translate-error message
-	translateError := ""
-	if len(untranslatedCodes) == 0 {
-		translateError = "All products have been translated"
-	} else {
-		translateError = "These products numbers does not have translating values + "
-		for i, code := range untranslatedCodes {
-			if i > 0 {
-				translateError += " + "
-			}
-			translateError += code
-		}
+	// Parse filtering, sorting and pagination options
+	opts, err := services.ParseBOMQueryOptions(r.URL.Query())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+	if !setTranslationProfile(w, r, &opts) {
+		return
+	}
+
+	if len(opts.Languages) > 1 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Only one language can be requested, use /api/bomcombined for several languages"})
+		return
+	}
+
+	// Call the service to get BOM data with Chinese translations and track failures
+	results, report, page, err := services.GetBOMByCodeWithTranslationTracking(r.Context(), conn, itemCode, opts)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	// List the items without translation
+	translateErrors := make([]TranslateError, 0, len(report.Untranslated))
+	for _, item := range report.Untranslated {
+		translateErrors = append(translateErrors, TranslateError{Code: item.Code, Name: item.Name})
 	}
 
-	// Create custom response with translate-error field
+	// Create custom response with translate-errors field
 	response := map[string]interface{}{
 		"data":                  results,
 		"count":                 len(results),
-		"translate-error":       translateError,
-		"translate-error-count": len(untranslatedCodes),
-		"message":               "BOM data with Chinese translations retrieved successfully",
+		"total":                 page.Total,
+		"next-cursor":           page.NextCursor,
+		"translate-errors":      translateErrors,
+		"translate-error-count": len(translateErrors),
+		"normalized-matches":    nonNilCodes(report.Normalized),
+		"rule-matches":          nonNilRules(report.Rules),
+		"composed-matches":      nonNilItems(report.Composed),
+		"translation-order":     services.TranslationOrder(),
+		"translation-set":       translationSetName(opts),
+		"translation-profile":   opts.TranslationProfile,
+		"message":               "BOM data with " + languageNames(opts.Languages) + " translations retrieved successfully",
 	}
 
 	// Return success response
@@ -113,35 +183,82 @@ func GetBOMByItemCodeCombined(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Call the service to get BOM data with both Turkish and Chinese and track failures
-	results, untranslatedCodes, err := services.GetBOMByCodeCombinedWithTracking(itemCode)
+	// Select the company database from ?company= or the X-Company header
+	conn, err := getConnection(r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	// Build translate-error message
-	translateError := ""
-	if len(untranslatedCodes) == 0 {
-		translateError = "All products have been translated"
-	} else {
-		translateError = "These products numbers does not have translating values + "
-		for i, code := range untranslatedCodes {
-			if i > 0 {
-				translateError += " + "
+	// Parse filtering, sorting and pagination options
+	opts, err := services.ParseBOMQueryOptions(r.URL.Query())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+	if !setTranslationProfile(w, r, &opts) {
+		return
+	}
+
+	// Call the service to get BOM data with Turkish and the requested languages and track failures
+	results, reports, page, err := services.GetBOMByCodeCombinedWithTracking(r.Context(), conn, itemCode, opts)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	// Collect the codes missing in any language, and the codes matched only after normalization
+	untranslatedByLanguage := make(map[string][]string)
+	normalizedByLanguage := make(map[string][]string)
+	rulesByLanguage := make(map[string]map[string]string)
+	composedByLanguage := make(map[string][]string)
+	errorsByCode := make(map[string]*TranslateError)
+	for lang, report := range reports {
+		for _, item := range report.Untranslated {
+			untranslatedByLanguage[lang] = append(untranslatedByLanguage[lang], item.Code)
+			if errorsByCode[item.Code] == nil {
+				errorsByCode[item.Code] = &TranslateError{Code: item.Code, Name: item.Name}
 			}
-			translateError += code
+			errorsByCode[item.Code].Languages = append(errorsByCode[item.Code].Languages, lang)
 		}
+		if len(report.Normalized) > 0 {
+			normalizedByLanguage[lang] = report.Normalized
+		}
+		if len(report.Rules) > 0 {
+			rulesByLanguage[lang] = report.Rules
+		}
+		for _, item := range report.Composed {
+			composedByLanguage[lang] = append(composedByLanguage[lang], item.Code)
+		}
+	}
+
+	translateErrors := make([]TranslateError, 0, len(errorsByCode))
+	for _, translateError := range errorsByCode {
+		sort.Strings(translateError.Languages)
+		translateErrors = append(translateErrors, *translateError)
 	}
+	sort.Slice(translateErrors, func(i, j int) bool {
+		return translateErrors[i].Code < translateErrors[j].Code
+	})
 
-	// Create custom response with translate-error field
+	// Create custom response with translate-errors field
 	response := map[string]interface{}{
 		"data":                  results,
 		"count":                 len(results),
-		"translate-error":       translateError,
-		"translate-error-count": len(untranslatedCodes),
-		"message":               "BOM data with Turkish and Chinese retrieved successfully",
+		"total":                 page.Total,
+		"next-cursor":           page.NextCursor,
+		"translate-errors":      translateErrors,
+		"translate-error-count": len(translateErrors),
+		"translate-error-by-lang": untranslatedByLanguage,
+		"normalized-matches-by-lang": normalizedByLanguage,
+		"rule-matches-by-lang":  rulesByLanguage,
+		"composed-matches-by-lang": composedByLanguage,
+		"translation-order":     services.TranslationOrder(),
+		"translation-set":       translationSetName(opts),
+		"translation-profile":   opts.TranslationProfile,
+		"message":               "BOM data with " + languageNames(append([]string{"tr"}, opts.Languages...)) + " retrieved successfully",
 	}
 
 	// Return success response
@@ -163,14 +280,21 @@ func GetBOMTotal(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Call the service to get unique codes with sequential numbers
-	results, err := services.GetBOMTotal(itemCode)
+	// Select the company database from ?company= or the X-Company header
+	conn, err := getConnection(r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
 		return
 	}
 
+	// Call the service to get unique codes with sequential numbers
+	results, err := services.GetBOMTotal(r.Context(), conn, itemCode)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
 	// Return success response
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(SuccessResponse{
@@ -195,10 +319,9 @@ func QueryHeihu(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Call the service to query Heihu API
-	result, err := services.QueryHeihu(itemCode)
+	result, err := services.QueryHeihu(r.Context(), itemCode)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		writeServiceError(w, r, err)
 		return
 	}
 
@@ -221,18 +344,26 @@ func CheckProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Call the service to check products
-	results, err := services.CheckProducts(itemCode)
+	// Select the company database from ?company= or the X-Company header
+	conn, err := getConnection(r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
 		return
 	}
 
+	// Call the service to check products
+	results, err := services.CheckProducts(r.Context(), conn, itemCode)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
 	// Calculate counts and build not-codes string
 	countOK := 0
 	countNOT := 0
 	var notCodes []string
+	unitMismatchCodes := []string{}
 
 	for _, result := range results {
 		if result.Status == "OK" {
@@ -241,6 +372,9 @@ func CheckProduct(w http.ResponseWriter, r *http.Request) {
 			countNOT++
 			notCodes = append(notCodes, result.Code)
 		}
+		if result.UnitMatch != nil && !*result.UnitMatch {
+			unitMismatchCodes = append(unitMismatchCodes, result.Code)
+		}
 	}
 
 	// Build the not-codes message
@@ -257,12 +391,14 @@ func CheckProduct(w http.ResponseWriter, r *http.Request) {
 
 	// Create custom response with additional fields
 	response := map[string]interface{}{
-		"data":       results,
-		"count":      len(results),
-		"count-ok":   countOK,
-		"count-not":  countNOT,
-		"not-codes":  notCodesMessage,
-		"message":    "Product check completed successfully",
+		"data":                results,
+		"count":               len(results),
+		"count-ok":            countOK,
+		"count-not":           countNOT,
+		"not-codes":           notCodesMessage,
+		"count-unit-mismatch": len(unitMismatchCodes),
+		"unit-mismatch-codes": unitMismatchCodes,
+		"message":             "Product check completed successfully",
 	}
 
 	// Return success response
@@ -278,4 +414,110 @@ func HealthCheck(w http.ResponseWriter, r *http.Request) {
 		"status": "ok",
 		"message": "API is running",
 	})
-}
\ No newline at end of file
+}
+
+// HealthCheckDB handles database health requests with connection pool statistics
+// Returns 503 Service Unavailable when any company database failed its last check
+func HealthCheckDB(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	connections := db.Health()
+
+	status := "ok"
+	statusCode := http.StatusOK
+	for _, conn := range connections {
+		if !conn.Healthy {
+			status = "degraded"
+			statusCode = http.StatusServiceUnavailable
+		}
+	}
+
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":      status,
+		"connections": connections,
+	})
+}
+
+// nonNilItems returns items, or an empty list so it is encoded as [] instead of null
+func nonNilItems(items []services.UntranslatedItem) []services.UntranslatedItem {
+	if items == nil {
+		return []services.UntranslatedItem{}
+	}
+	return items
+}
+
+// nonNilCodes returns codes, or an empty list so it is encoded as [] instead of null
+func nonNilCodes(codes []string) []string {
+	if codes == nil {
+		return []string{}
+	}
+	return codes
+}
+
+// nonNilRules returns the rule ids by item code, or an empty map so it is encoded as {} instead of null
+func nonNilRules(rules map[string]string) map[string]string {
+	if rules == nil {
+		return map[string]string{}
+	}
+	return rules
+}
+
+// translationSetName returns the translation set a BOM was translated with
+func translationSetName(opts services.BOMQueryOptions) string {
+	if opts.TranslationSet == "" {
+		return services.ActiveTranslationSet
+	}
+	return opts.TranslationSet
+}
+
+// languageDisplayNames are the names of the languages in response messages, other languages are shown by code
+var languageDisplayNames = map[string]string{
+	"tr": "Turkish",
+	"cn": "Chinese",
+	"en": "English",
+	"ru": "Russian",
+}
+
+// languageNames lists languages for a response message, e.g. "English and Russian"
+// No language means the default one
+func languageNames(langs []string) string {
+	if len(langs) == 0 || len(langs) == 1 && langs[0] == "tr" {
+		langs = append(langs, services.DefaultLanguage)
+	}
+
+	names := make([]string, len(langs))
+	for i, lang := range langs {
+		names[i] = lang
+		if name, exists := languageDisplayNames[lang]; exists {
+			names[i] = name
+		}
+	}
+	if len(names) == 1 {
+		return names[0]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+}
+
+// setTranslationProfile selects the profile of the customer owning the X-API-Key header
+// A key always gets its own profile, ?profile= naming another one is rejected; without a profile key
+// only admin callers may pick a profile with ?profile=. Writes 403 Forbidden and returns false otherwise
+func setTranslationProfile(w http.ResponseWriter, r *http.Request, opts *services.BOMQueryOptions) bool {
+	keyProfile := services.TranslationProfileForKey(r.Header.Get("X-API-Key"))
+	switch {
+	case keyProfile != "":
+		if opts.TranslationProfile != "" && opts.TranslationProfile != keyProfile {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "profile " + opts.TranslationProfile + " does not belong to this API key"})
+			return false
+		}
+		opts.TranslationProfile = keyProfile
+	case opts.TranslationProfile != "":
+		if _, ok := adminIdentity(r); !ok {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "profile requires the API key of the customer or an admin token"})
+			return false
+		}
+	}
+	return true
+}
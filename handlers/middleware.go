@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"resco/services"
+	"time"
+)
+
+// WithTimeout limits the time a handler may spend on a request
+// The deadline is carried by the request context, a zero timeout disables the limit
+func WithTimeout(timeout time.Duration, next http.HandlerFunc) http.HandlerFunc {
+	if timeout <= 0 {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// writeServiceError writes the error returned by a service call
+// Requests that ran out of time get 504 Gateway Timeout, other failures 500 Internal Server Error
+func writeServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	switch r.Context().Err() {
+	case context.DeadlineExceeded:
+		w.WriteHeader(http.StatusGatewayTimeout)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "request timed out: " + err.Error()})
+	case context.Canceled:
+		// The client disconnected, there is nobody to answer
+		log.Printf("Request %s %s cancelled by client: %v", r.Method, r.URL.Path, err)
+	default:
+		if errors.Is(err, services.ErrUnknownLanguage) || errors.Is(err, services.ErrTranslationSetNotFound) || errors.Is(err, services.ErrTranslationProfileNotFound) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+	}
+}
@@ -0,0 +1,1007 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"resco/db"
+	"resco/services"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+type TranslationRequest struct {
+	Prefix string `json:"prefix,omitempty"`
+	Code   string `json:"code,omitempty"`
+	Source string `json:"source,omitempty"`
+	Target string `json:"target"`
+}
+
+// ReloadTranslations handles POST requests to reload the translation dictionaries from disk
+// Invalid files are rejected with 422 and the previous translations stay active
+func ReloadTranslations(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	result, err := services.ReloadTranslations()
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":   err.Error(),
+			"active":  services.LastTranslationReload(),
+			"message": "Translations were not reloaded, previous translations are still active",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    result,
+		"message": "Translations reloaded successfully",
+	})
+}
+
+// GetDirectTranslations handles GET requests for the direct translation dictionary
+// The dictionary version is returned in the ETag header
+func GetDirectTranslations(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	lang, ok := getLanguage(w, r)
+	if !ok {
+		return
+	}
+
+	entries, version, err := services.GetDirectTranslations(lang)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", formatETag(version))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    entries,
+		"count":   len(entries),
+		"version": version,
+		"lang":    lang,
+		"message": "Direct translations retrieved successfully",
+	})
+}
+
+// PutDirectTranslation handles PUT requests to create or update a direct translation
+// Requires an If-Match header with the version from GET ("*" to overwrite unconditionally)
+func PutDirectTranslation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	lang, ok := getLanguage(w, r)
+	if !ok {
+		return
+	}
+
+	version, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var req TranslationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid request body: " + err.Error()})
+		return
+	}
+
+	newVersion, err := services.PutDirectTranslation(lang, req.Source, req.Target, version, getAuthor(r))
+	warning, err := reloadWarning(err)
+	if err != nil {
+		writeTranslationStoreError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", formatETag(newVersion))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(withWarning(map[string]interface{}{
+		"data":    req,
+		"version": newVersion,
+		"message": "Direct translation saved successfully",
+	}, warning))
+}
+
+// DeleteDirectTranslation handles DELETE requests for a direct translation (?source=)
+func DeleteDirectTranslation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	lang, ok := getLanguage(w, r)
+	if !ok {
+		return
+	}
+
+	version, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	source := r.URL.Query().Get("source")
+	if source == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "source is required"})
+		return
+	}
+
+	newVersion, err := services.DeleteDirectTranslation(lang, source, version, getAuthor(r))
+	warning, err := reloadWarning(err)
+	if err != nil {
+		writeTranslationStoreError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", formatETag(newVersion))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(withWarning(map[string]interface{}{
+		"version": newVersion,
+		"message": "Direct translation deleted successfully",
+	}, warning))
+}
+
+// GetFallbackTranslations handles GET requests for the prefix fallback dictionary
+func GetFallbackTranslations(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	lang, ok := getLanguage(w, r)
+	if !ok {
+		return
+	}
+
+	entries, version, err := services.GetFallbackTranslations(lang)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", formatETag(version))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    entries,
+		"count":   len(entries),
+		"version": version,
+		"message": "Fallback translations retrieved successfully",
+	})
+}
+
+// PutFallbackTranslation handles PUT requests to create or update a prefix fallback translation
+func PutFallbackTranslation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	lang, ok := getLanguage(w, r)
+	if !ok {
+		return
+	}
+
+	version, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var req TranslationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid request body: " + err.Error()})
+		return
+	}
+
+	newVersion, err := services.PutFallbackTranslation(lang, req.Prefix, req.Source, req.Target, version, getAuthor(r))
+	warning, err := reloadWarning(err)
+	if err != nil {
+		writeTranslationStoreError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", formatETag(newVersion))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(withWarning(map[string]interface{}{
+		"data":    req,
+		"version": newVersion,
+		"message": "Fallback translation saved successfully",
+	}, warning))
+}
+
+// DeleteFallbackTranslation handles DELETE requests for a prefix fallback translation (?prefix=&source=)
+func DeleteFallbackTranslation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	lang, ok := getLanguage(w, r)
+	if !ok {
+		return
+	}
+
+	version, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	prefix := r.URL.Query().Get("prefix")
+	source := r.URL.Query().Get("source")
+	if prefix == "" || source == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "prefix and source are required"})
+		return
+	}
+
+	newVersion, err := services.DeleteFallbackTranslation(lang, prefix, source, version, getAuthor(r))
+	warning, err := reloadWarning(err)
+	if err != nil {
+		writeTranslationStoreError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", formatETag(newVersion))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(withWarning(map[string]interface{}{
+		"version": newVersion,
+		"message": "Fallback translation deleted successfully",
+	}, warning))
+}
+
+// GetCodeTranslations handles GET requests for the item code override dictionary
+func GetCodeTranslations(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	lang, ok := getLanguage(w, r)
+	if !ok {
+		return
+	}
+
+	entries, version, err := services.GetCodeTranslations(lang)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", formatETag(version))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    entries,
+		"count":   len(entries),
+		"version": version,
+		"order":   services.TranslationOrder(),
+		"message": "Item code translations retrieved successfully",
+	})
+}
+
+// PutCodeTranslation handles PUT requests to create or update an item code override
+func PutCodeTranslation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	lang, ok := getLanguage(w, r)
+	if !ok {
+		return
+	}
+
+	version, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var req TranslationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid request body: " + err.Error()})
+		return
+	}
+
+	newVersion, err := services.PutCodeTranslation(lang, req.Code, req.Target, version, getAuthor(r))
+	warning, err := reloadWarning(err)
+	if err != nil {
+		writeTranslationStoreError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", formatETag(newVersion))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(withWarning(map[string]interface{}{
+		"data":    req,
+		"version": newVersion,
+		"message": "Item code translation saved successfully",
+	}, warning))
+}
+
+// DeleteCodeTranslation handles DELETE requests for an item code override (?code=)
+func DeleteCodeTranslation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	lang, ok := getLanguage(w, r)
+	if !ok {
+		return
+	}
+
+	version, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	code := r.URL.Query().Get("code")
+	if code == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "code is required"})
+		return
+	}
+
+	newVersion, err := services.DeleteCodeTranslation(lang, code, version, getAuthor(r))
+	warning, err := reloadWarning(err)
+	if err != nil {
+		writeTranslationStoreError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", formatETag(newVersion))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(withWarning(map[string]interface{}{
+		"version": newVersion,
+		"message": "Item code translation deleted successfully",
+	}, warning))
+}
+
+// GetTranslationHistory handles GET requests for the change history of the translations
+// Optional filters: lang, scope (direct, prefix, code), key (prefix or item code), source, limit
+func GetTranslationHistory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := r.URL.Query()
+	filter := services.TranslationHistoryFilter{
+		Language: strings.ToLower(strings.TrimSpace(query.Get("lang"))),
+		Scope:    query.Get("scope"),
+		Key:      query.Get("key"),
+		Source:   query.Get("source"),
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "limit must be a positive number"})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	// Call the service to get the history
+	entries, err := services.GetTranslationHistory(filter)
+	if errors.Is(err, services.ErrHistoryUnavailable) {
+		w.WriteHeader(http.StatusNotImplemented)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    entries,
+		"count":   len(entries),
+		"message": "Translation history retrieved successfully",
+	})
+}
+
+// GetMissingTranslations handles GET requests for the untranslated names of the catalog
+// ?roots= limits the scan to the BOMs of a comma separated list of finished goods, ?format=csv returns a CSV file
+func GetMissingTranslations(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	lang, ok := getLanguage(w, r)
+	if !ok {
+		return
+	}
+
+	// Select the company database from ?company= or the X-Company header
+	conn, err := getConnection(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	opts := services.MissingTranslationOptions{Language: lang}
+	for _, root := range strings.Split(r.URL.Query().Get("roots"), ",") {
+		if root = strings.TrimSpace(root); root != "" {
+			opts.Roots = append(opts.Roots, root)
+		}
+	}
+	if value := r.URL.Query().Get("prefixLength"); value != "" {
+		opts.PrefixLength, err = strconv.Atoi(value)
+		if err != nil || opts.PrefixLength <= 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "prefixLength must be a positive number"})
+			return
+		}
+	}
+
+	// Call the service to scan the catalog
+	report, err := services.GetMissingTranslations(r.Context(), conn, opts)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "csv" {
+		writeMissingTranslationsCSV(w, report)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    report,
+		"count":   len(report.Names),
+		"message": "Missing translations retrieved successfully",
+	})
+}
+
+// writeMissingTranslationsCSV writes the missing names as a CSV file, one row per name
+// The target column is prefilled with the composed candidate, if any
+func writeMissingTranslationsCSV(w http.ResponseWriter, report services.MissingTranslationReport) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="missing-translations-%s.csv"`, report.Language))
+	w.WriteHeader(http.StatusOK)
+
+	// UTF-8 byte order mark so Excel shows Turkish characters correctly
+	w.Write([]byte("\xEF\xBB\xBF"))
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"name", "count", "prefixes", "sample-codes", "target"})
+	for _, missing := range report.Names {
+		prefixes := make([]string, 0, len(missing.Prefixes))
+		for prefix, count := range missing.Prefixes {
+			prefixes = append(prefixes, fmt.Sprintf("%s:%d", prefix, count))
+		}
+		sort.Strings(prefixes)
+
+		writer.Write([]string{
+			missing.Name,
+			strconv.Itoa(missing.Count),
+			strings.Join(prefixes, " "),
+			strings.Join(missing.SampleCodes, " "),
+			missing.Composed,
+		})
+	}
+	writer.Flush()
+}
+
+// maxReverseLimit limits the number of reverse lookup matches
+const maxReverseLimit = 100
+
+// maxUsageTop caps the number of top entries and misses of a usage report
+const maxUsageTop = 1000
+
+// ReverseTranslation handles GET /api/translations/reverse?lang=cn&q=减震器
+// Returns the dictionary entries whose translation matches q and the active items using them
+func ReverseTranslation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	lang, ok := getLanguage(w, r)
+	if !ok {
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "q is required"})
+		return
+	}
+
+	limit := services.DefaultReverseLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxReverseLimit {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("limit must be between 1 and %d", maxReverseLimit)})
+			return
+		}
+	}
+
+	// Select the company database from ?company= or the X-Company header
+	conn, err := getConnection(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// Call the service to search the translations
+	result, err := services.ReverseTranslate(r.Context(), conn, lang, query, limit)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    result,
+		"count":   len(result.Matches),
+		"message": "Reverse translation lookup completed successfully",
+	})
+}
+
+// GetTranslationUsage handles GET /api/translations/stats?lang=cn&days=30&top=20
+// Reports the most used entries, rule hits, unused entries and the most frequent misses of the last days
+func GetTranslationUsage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	lang, ok := getLanguage(w, r)
+	if !ok {
+		return
+	}
+
+	days := services.DefaultUsageDays
+	if value := r.URL.Query().Get("days"); value != "" {
+		var err error
+		days, err = strconv.Atoi(value)
+		retention := services.UsageRetentionDays()
+		if err != nil || days <= 0 || (retention > 0 && days > retention) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("days must be between 1 and the retention of %d days", retention)})
+			return
+		}
+	}
+
+	top := services.DefaultUsageTop
+	if value := r.URL.Query().Get("top"); value != "" {
+		var err error
+		top, err = strconv.Atoi(value)
+		if err != nil || top <= 0 || top > maxUsageTop {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("top must be between 1 and %d", maxUsageTop)})
+			return
+		}
+	}
+
+	// Call the service to aggregate the usage
+	report, err := services.GetTranslationUsage(lang, days, top)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	message := "Translation usage retrieved successfully"
+	if !report.Tracking {
+		message = "Translation usage tracking is disabled (TRANSLATION_USAGE_FLUSH_INTERVAL=0)"
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    report,
+		"count":   len(report.Unused),
+		"message": message,
+	})
+}
+
+// GetValueTranslations handles GET /api/translations/values?lang=cn
+// Returns the mapping of enumerated ERP values (units) by field
+func GetValueTranslations(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	lang, ok := getLanguage(w, r)
+	if !ok {
+		return
+	}
+
+	// Call the service to get the value mapping
+	values, err := services.GetValueTranslations(lang)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    values,
+		"count":   len(values),
+		"message": "Value translations retrieved successfully",
+	})
+}
+
+// GetTranslationProfiles handles GET /admin/translations/profiles
+// Lists the customer profiles with their entry counts per language
+func GetTranslationProfiles(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Call the service to list the profiles
+	profiles, err := services.ListTranslationProfiles()
+	if err != nil {
+		writeTranslationStoreError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    profiles,
+		"count":   len(profiles),
+		"message": "Translation profiles retrieved successfully",
+	})
+}
+
+// LintTranslations handles GET /admin/translations/lint?lang=cn
+// Reports suspicious dictionary entries: repeated words, shared targets, untranslated targets, whitespace, case variants
+func LintTranslations(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	lang, ok := getLanguage(w, r)
+	if !ok {
+		return
+	}
+
+	// Call the service to lint the dictionaries
+	report, err := services.LintTranslations(lang)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    report,
+		"count":   len(report.Issues),
+		"message": "Translations linted successfully",
+	})
+}
+
+// GetTranslationSuggestions handles GET /admin/translations/suggestions?lang=cn&status=pending
+// Lists the suggestions of the translators, pending ones unless another status (or "all") is requested
+func GetTranslationSuggestions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	lang, ok := getLanguage(w, r)
+	if !ok {
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = services.SuggestionPending
+	case "all":
+		status = ""
+	case services.SuggestionPending, services.SuggestionApproved, services.SuggestionRejected:
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "status must be pending, approved, rejected or all"})
+		return
+	}
+
+	// Call the service to get the suggestions
+	suggestions := services.GetTranslationSuggestions(lang, status)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    suggestions,
+		"count":   len(suggestions),
+		"message": "Translation suggestions retrieved successfully",
+	})
+}
+
+// ApproveTranslationSuggestion handles POST /admin/translations/suggestions/{id}/approve
+// Adds the suggestion to the direct dictionary; an optional {"target": "..."} body corrects the suggested text
+func ApproveTranslationSuggestion(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, ok := getSuggestionID(w, r)
+	if !ok {
+		return
+	}
+
+	var req TranslationRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "invalid request body: " + err.Error()})
+			return
+		}
+	}
+
+	// Call the service to approve the suggestion
+	suggestion, err := services.ApproveTranslationSuggestion(id, strings.TrimSpace(req.Target), getAuthor(r))
+	if err != nil {
+		writeSuggestionError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    suggestion,
+		"message": "Translation suggestion approved",
+	})
+}
+
+// RejectTranslationSuggestion handles POST /admin/translations/suggestions/{id}/reject
+func RejectTranslationSuggestion(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, ok := getSuggestionID(w, r)
+	if !ok {
+		return
+	}
+
+	// Call the service to reject the suggestion
+	suggestion, err := services.RejectTranslationSuggestion(id, getAuthor(r))
+	if err != nil {
+		writeSuggestionError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    suggestion,
+		"message": "Translation suggestion rejected",
+	})
+}
+
+// getSuggestionID reads the suggestion id from the path, writing 400 Bad Request when it is not a number
+func getSuggestionID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "suggestion id must be a number"})
+		return 0, false
+	}
+	return id, true
+}
+
+// writeSuggestionError maps review errors to HTTP status codes
+func writeSuggestionError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrSuggestionNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+	case errors.Is(err, services.ErrSuggestionReviewed):
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+	default:
+		writeTranslationStoreError(w, r, err)
+	}
+}
+
+// maxTranslationSheetSize limits the size of uploaded translation sheets
+const maxTranslationSheetSize = 20 << 20
+
+// ExportTranslations handles GET /admin/translations/export?lang=cn&format=xlsx
+// Returns all dictionary entries of a language as a spreadsheet for translators, ?usage=false skips the catalog scan
+func ExportTranslations(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	lang, ok := getLanguage(w, r)
+	if !ok {
+		return
+	}
+
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		format = services.FormatXLSX
+	}
+	if format != services.FormatXLSX && format != services.FormatCSV {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "format must be xlsx or csv"})
+		return
+	}
+
+	withUsage := true
+	if value := r.URL.Query().Get("usage"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "usage must be true or false"})
+			return
+		}
+		withUsage = parsed
+	}
+
+	// Usage counts come from the catalog of the selected company
+	var conn *db.Connection
+	if withUsage {
+		var err error
+		conn, err = getConnection(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+
+	// Call the service to collect the entries
+	rows, err := services.ExportTranslations(r.Context(), conn, lang)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	// Encode before writing the headers so errors can still be reported as JSON
+	var buf bytes.Buffer
+	if err := services.WriteTranslationSheet(&buf, format, rows); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if format == services.FormatXLSX {
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="translations-%s.%s"`, lang, format))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// ImportTranslations handles POST /admin/translations/import?lang=cn&dryRun=true
+// The sheet is sent as the request body or as the "file" field of a multipart form
+// Returns the diff against the current dictionaries, which is applied unless dryRun is set
+func ImportTranslations(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	lang, ok := getLanguage(w, r)
+	if !ok {
+		return
+	}
+
+	dryRun := false
+	if value := r.URL.Query().Get("dryRun"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "dryRun must be true or false"})
+			return
+		}
+		dryRun = parsed
+	}
+
+	data, filename, err := readUploadedSheet(w, r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		format = services.DetectSheetFormat(filename, data)
+	}
+
+	rows, err := services.ParseTranslationSheet(data, format)
+	if err != nil {
+		writeTranslationStoreError(w, r, err)
+		return
+	}
+
+	// Call the service to compare and apply the sheet
+	result, err := services.ImportTranslations(lang, rows, getAuthor(r), dryRun)
+	if errors.Is(err, services.ErrInvalidTranslation) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": err.Error(),
+			"data":  result,
+		})
+		return
+	}
+	if err != nil {
+		writeTranslationStoreError(w, r, err)
+		return
+	}
+
+	message := "Translations imported successfully"
+	if dryRun {
+		message = "Translation import previewed, nothing was changed"
+	} else if !result.Applied {
+		message = "Translations are already up to date"
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    result,
+		"count":   len(result.Added) + len(result.Changed),
+		"message": message,
+	})
+}
+
+// readUploadedSheet returns the uploaded file and its name from a multipart form or the raw request body
+func readUploadedSheet(w http.ResponseWriter, r *http.Request) ([]byte, string, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxTranslationSheetSize)
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			return nil, "", fmt.Errorf("error reading uploaded file: %v", err)
+		}
+		defer file.Close()
+
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return nil, "", fmt.Errorf("error reading uploaded file: %v", err)
+		}
+		return data, header.Filename, nil
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("error reading request body: %v", err)
+	}
+	if len(data) == 0 {
+		return nil, "", fmt.Errorf("request body is empty")
+	}
+	return data, "", nil
+}
+
+// getLanguage reads the target language from ?lang=, defaulting to Chinese
+func getLanguage(w http.ResponseWriter, r *http.Request) (string, bool) {
+	lang := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("lang")))
+	if lang == "" {
+		return services.DefaultLanguage, true
+	}
+	if err := services.ValidateLanguage(lang); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return "", false
+	}
+	return lang, true
+}
+
+// getAuthor returns the author recorded with a translation change, the holder of the admin token of the request
+func getAuthor(r *http.Request) string {
+	if name, ok := r.Context().Value(adminContextKey{}).(string); ok {
+		return name
+	}
+	return "api"
+}
+
+// reloadWarning separates a failed reload after a saved change from other errors,
+// the change is on disk so it is answered with the new version and the reload error as a warning
+func reloadWarning(err error) (string, error) {
+	if errors.Is(err, services.ErrTranslationReloadFailed) {
+		return err.Error(), nil
+	}
+	return "", err
+}
+
+// withWarning adds a non-empty warning to a response
+func withWarning(response map[string]interface{}, warning string) map[string]interface{} {
+	if warning != "" {
+		response["warning"] = warning
+	}
+	return response
+}
+
+// requireIfMatch reads the If-Match header, writing 428 Precondition Required when it is missing
+func requireIfMatch(w http.ResponseWriter, r *http.Request) (string, bool) {
+	ifMatch := strings.TrimSpace(r.Header.Get("If-Match"))
+	if ifMatch == "" {
+		w.WriteHeader(http.StatusPreconditionRequired)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "If-Match header with the dictionary version is required"})
+		return "", false
+	}
+	ifMatch = strings.TrimPrefix(ifMatch, "W/")
+	return strings.Trim(ifMatch, `"`), true
+}
+
+func formatETag(version string) string {
+	return `"` + version + `"`
+}
+
+// writeTranslationStoreError maps translation store errors to HTTP status codes
+func writeTranslationStoreError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrVersionMismatch):
+		w.WriteHeader(http.StatusPreconditionFailed)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+	case errors.Is(err, services.ErrTranslationNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+	case errors.Is(err, services.ErrInvalidTranslation), errors.Is(err, services.ErrUnknownLanguage):
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+	default:
+		writeServiceError(w, r, err)
+	}
+}
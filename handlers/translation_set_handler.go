@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"resco/services"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// TranslationSetRequest is the body of a request creating a translation set
+type TranslationSetRequest struct {
+	Name string `json:"name"`
+	From string `json:"from,omitempty"`
+	Note string `json:"note,omitempty"`
+}
+
+// GetTranslationSets handles GET /admin/translations/sets
+// Lists the active translations and the stored sets (drafts and archives) with their entry counts
+func GetTranslationSets(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Call the service to list the sets
+	sets, err := services.ListTranslationSets()
+	if err != nil {
+		writeTranslationSetError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    sets,
+		"count":   len(sets),
+		"message": "Translation sets retrieved successfully",
+	})
+}
+
+// CreateTranslationSet handles POST /admin/translations/sets with {"name": "draft", "from": "active"}
+// The new set is a draft copy of every language of the source set
+func CreateTranslationSet(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req TranslationSetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "invalid request body: " + err.Error()})
+		return
+	}
+
+	// Call the service to copy the set
+	set, err := services.CreateTranslationSet(strings.TrimSpace(req.Name), strings.TrimSpace(req.From), strings.TrimSpace(req.Note), getAuthor(r))
+	if err != nil {
+		writeTranslationSetError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    set,
+		"message": "Translation set created successfully",
+	})
+}
+
+// DiffTranslationSets handles GET /admin/translations/sets/diff?lang=cn&from=active&to=draft
+// Lists the entries added, removed and changed by going from one set to the other
+func DiffTranslationSets(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	lang, ok := getLanguage(w, r)
+	if !ok {
+		return
+	}
+
+	from := r.URL.Query().Get("from")
+	if from == "" {
+		from = services.ActiveTranslationSet
+	}
+	to := r.URL.Query().Get("to")
+	if to == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "to is required"})
+		return
+	}
+
+	// Call the service to compare the sets
+	diff, err := services.DiffTranslationSets(lang, from, to)
+	if err != nil {
+		writeTranslationSetError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    diff,
+		"count":   len(diff.Changes),
+		"message": "Translation sets compared successfully",
+	})
+}
+
+// PromoteTranslationSet handles POST /admin/translations/sets/{name}/promote?lang=cn
+// The active translations are archived and replaced by the set
+func PromoteTranslationSet(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	lang, ok := getLanguage(w, r)
+	if !ok {
+		return
+	}
+
+	// Call the service to promote the set
+	promotion, err := services.PromoteTranslationSet(mux.Vars(r)["name"], lang, getAuthor(r))
+	if err != nil {
+		writeTranslationSetError(w, r, err)
+		return
+	}
+
+	message := "Translation set promoted successfully"
+	if promotion.Archived == "" {
+		message = "Translation set is identical to the active translations, nothing changed"
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    promotion,
+		"message": message,
+	})
+}
+
+// RollbackTranslationSet handles POST /admin/translations/sets/rollback?lang=cn
+// Restores the active translations replaced by the latest promotion
+func RollbackTranslationSet(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	lang, ok := getLanguage(w, r)
+	if !ok {
+		return
+	}
+
+	// Call the service to restore the latest archive
+	promotion, err := services.RollbackTranslationSet(lang, getAuthor(r))
+	if err != nil {
+		writeTranslationSetError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    promotion,
+		"message": "Translations rolled back to " + promotion.Promoted,
+	})
+}
+
+// writeTranslationSetError maps translation set errors to HTTP status codes
+func writeTranslationSetError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrTranslationSetNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+	case errors.Is(err, services.ErrTranslationSetExists), errors.Is(err, services.ErrNothingToRollBack):
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+	case errors.Is(err, services.ErrInvalidTranslationSet):
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+	default:
+		writeTranslationStoreError(w, r, err)
+	}
+}
@@ -1,12 +1,17 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
 	"net/http"
 	"os"
 	"resco/db"
 	"resco/handlers"
+	"resco/services"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
@@ -20,34 +25,148 @@ func main() {
 	}
 
 	// Load database configuration from environment variables
-	dbConfig := db.Config{
-		Server:   getEnv("DB_SERVER", "localhost"),
-		Port:     getEnvAsInt("DB_PORT", 1433),
-		User:     getEnv("DB_USER", "sa"),
-		Password: getEnv("DB_PASSWORD", ""),
-		Database: getEnv("DB_DATABASE", "RESCO_2019"),
+	dbConfigs := loadDBConfigs()
+
+	// "resco export-translations" and "resco import-translations" exchange translation sheets and exit,
+	// "resco lint-translations" reports suspicious dictionary entries and exits with status 1 if there are any
+	// They run before the server starts and only connect to the database when they need it
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "export-translations", "import-translations", "lint-translations":
+			runTranslationCommand(dbConfigs, os.Args[1], os.Args[2:])
+			return
+		}
+	}
+
+	// Initialize database connections (one per company)
+	openDatabases(dbConfigs)
+	defer db.CloseDB()
+
+	// "resco migrate-translations" imports the JSON files into the translation table and exits
+	if len(os.Args) > 1 && os.Args[1] == "migrate-translations" {
+		migrateTranslations(initSQLTranslationBackend(), os.Args[2:])
+		return
+	}
+
+	// Ping the databases periodically so /health/db reflects outages
+	stopHealthProbe := db.StartHealthProbe(getEnvAsDuration("DB_HEALTH_INTERVAL", 30*time.Second))
+	defer stopHealthProbe()
+
+	configureTranslations(dbConfigs)
+
+	// Load the translation dictionaries and reload them when the files change
+	if result, err := services.ReloadTranslations(); err != nil {
+		log.Printf("Warning: translations could not be loaded: %v", err)
+	} else {
+		for lang, stats := range result.Languages {
+			log.Printf("Translations loaded (%s): %d direct entries, %d fallback entries, %d item code overrides",
+				lang, stats.DirectEntries, stats.FallbackEntries, stats.CodeOverrides)
+			if stats.NormalizedCollisions > 0 {
+				log.Printf("Warning: %d %s dictionary keys are shadowed by other keys with the same normalized name",
+					stats.NormalizedCollisions, lang)
+			}
+		}
+		for _, warning := range result.Warnings {
+			log.Printf("Translation warning: %s", warning)
+		}
+	}
+	stopTranslationWatch := services.WatchTranslations(getEnvAsDuration("TRANSLATION_WATCH_INTERVAL", 5*time.Second))
+	defer stopTranslationWatch()
+
+	// Per-endpoint request timeouts (0 disables the limit)
+	bomTimeout := getEnvAsDuration("TIMEOUT_BOM", 60*time.Second)
+	heihuTimeout := getEnvAsDuration("TIMEOUT_HEIHU", 30*time.Second)
+	checkProductTimeout := getEnvAsDuration("TIMEOUT_CHECKPRODUCT", 10*time.Minute)
+	missingTranslationsTimeout := getEnvAsDuration("TIMEOUT_MISSING_TRANSLATIONS", 5*time.Minute)
+
+	// Active items scanned by the missing translations report, e.g. STOCK_ACTIVE_COLUMN=AKTIF, STOCK_ACTIVE_VALUE=1
+	if err := services.SetActiveItemFilter(getEnv("STOCK_ACTIVE_COLUMN", ""), getEnv("STOCK_ACTIVE_VALUE", "1")); err != nil {
+		log.Fatalf("Invalid STOCK_ACTIVE_COLUMN: %v", err)
+	}
+
+	// Unit of measure of STOK00 items, translated in BOMs and compared with Heihu, e.g. STOCK_UNIT_COLUMN=BIRIM
+	if err := services.SetItemUnitColumn(getEnv("STOCK_UNIT_COLUMN", "")); err != nil {
+		log.Fatalf("Invalid STOCK_UNIT_COLUMN: %v", err)
+	}
+
+	// API keys selecting a customer translation profile, e.g. TRANSLATION_PROFILE_KEYS=k3y1=acme,k3y2=globex
+	if err := services.SetTranslationProfileKeys(getEnv("TRANSLATION_PROFILE_KEYS", "")); err != nil {
+		log.Fatalf("Invalid TRANSLATION_PROFILE_KEYS: %v", err)
 	}
 
-	// Initialize database connection
-	err = db.InitDB(dbConfig)
+	// Translators suggesting names the dictionaries cannot translate, reviewed via /admin/translations/suggestions
+	// e.g. TRANSLATION_SUGGESTIONS=glossary (default), "none" disables them
+	var translators []services.Translator
+	if names := getEnv("TRANSLATION_SUGGESTIONS", "glossary"); names != "none" {
+		translators, err = services.TranslatorsByName(strings.Split(names, ","))
+		if err != nil {
+			log.Fatalf("Invalid TRANSLATION_SUGGESTIONS: %v", err)
+		}
+	}
+	stopSuggestions, err := services.StartTranslationSuggestions(translators)
 	if err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+		log.Fatalf("Failed to load translation suggestions: %v", err)
 	}
-	defer db.CloseDB()
+	defer stopSuggestions()
 
-	log.Println("Database connected successfully")
+	// Usage statistics of the dictionaries, saved to translate/usage.json, reported by /api/translations/stats
+	stopUsage, err := services.StartTranslationUsage(getEnvAsDuration("TRANSLATION_USAGE_FLUSH_INTERVAL", time.Minute), getEnvAsInt("TRANSLATION_USAGE_DAYS", 90))
+	if err != nil {
+		log.Fatalf("Failed to load translation usage: %v", err)
+	}
+	defer stopUsage()
+
+	// Tokens of the admin API, the name of the holder is recorded as author, e.g. ADMIN_TOKENS=t0k3n1=li.wei
+	if err := handlers.SetAdminTokens(getEnv("ADMIN_TOKENS", "")); err != nil {
+		log.Fatalf("Invalid ADMIN_TOKENS: %v", err)
+	}
+	if !handlers.AdminTokensConfigured() {
+		log.Println("Warning: ADMIN_TOKENS is not set, the /admin endpoints are disabled")
+	}
 
 	// Create router
 	router := mux.NewRouter()
 
+	// Admin routes require a token
+	admin := router.NewRoute().Subrouter()
+	admin.Use(handlers.RequireAdmin)
+
 	// Register routes
 	router.HandleFunc("/health", handlers.HealthCheck).Methods("GET")
-	router.HandleFunc("/api/bom/{itemCode}", handlers.GetBOMByItemCode).Methods("GET")
-	router.HandleFunc("/api/bomcn/{itemCode}", handlers.GetBOMByItemCodeCN).Methods("GET")
-	router.HandleFunc("/api/bomcombined/{itemCode}", handlers.GetBOMByItemCodeCombined).Methods("GET")
-	router.HandleFunc("/api/bomtotal/{itemCode}", handlers.GetBOMTotal).Methods("GET")
-	router.HandleFunc("/api/queryhe/{itemCode}", handlers.QueryHeihu).Methods("GET")
-	router.HandleFunc("/api/checkproduct/{itemCode}", handlers.CheckProduct).Methods("GET")
+	router.HandleFunc("/health/db", handlers.HealthCheckDB).Methods("GET")
+	router.HandleFunc("/api/bom/{itemCode}", handlers.WithTimeout(bomTimeout, handlers.GetBOMByItemCode)).Methods("GET")
+	router.HandleFunc("/api/bomcn/{itemCode}", handlers.WithTimeout(bomTimeout, handlers.GetBOMByItemCodeCN)).Methods("GET")
+	router.HandleFunc("/api/bomcombined/{itemCode}", handlers.WithTimeout(bomTimeout, handlers.GetBOMByItemCodeCombined)).Methods("GET")
+	router.HandleFunc("/api/bomtotal/{itemCode}", handlers.WithTimeout(bomTimeout, handlers.GetBOMTotal)).Methods("GET")
+	router.HandleFunc("/api/queryhe/{itemCode}", handlers.WithTimeout(heihuTimeout, handlers.QueryHeihu)).Methods("GET")
+	router.HandleFunc("/api/checkproduct/{itemCode}", handlers.WithTimeout(checkProductTimeout, handlers.CheckProduct)).Methods("GET")
+	router.HandleFunc("/api/translations/reverse", handlers.WithTimeout(bomTimeout, handlers.ReverseTranslation)).Methods("GET")
+	router.HandleFunc("/api/translations/stats", handlers.GetTranslationUsage).Methods("GET")
+	router.HandleFunc("/api/translations/values", handlers.GetValueTranslations).Methods("GET")
+	router.HandleFunc("/api/translations/missing", handlers.WithTimeout(missingTranslationsTimeout, handlers.GetMissingTranslations)).Methods("GET")
+	admin.HandleFunc("/admin/translations/history", handlers.GetTranslationHistory).Methods("GET")
+	admin.HandleFunc("/admin/translations/export", handlers.WithTimeout(missingTranslationsTimeout, handlers.ExportTranslations)).Methods("GET")
+	admin.HandleFunc("/admin/translations/import", handlers.ImportTranslations).Methods("POST")
+	admin.HandleFunc("/admin/translations/lint", handlers.LintTranslations).Methods("GET")
+	admin.HandleFunc("/admin/translations/suggestions", handlers.GetTranslationSuggestions).Methods("GET")
+	admin.HandleFunc("/admin/translations/suggestions/{id}/approve", handlers.ApproveTranslationSuggestion).Methods("POST")
+	admin.HandleFunc("/admin/translations/suggestions/{id}/reject", handlers.RejectTranslationSuggestion).Methods("POST")
+	admin.HandleFunc("/admin/translations/profiles", handlers.GetTranslationProfiles).Methods("GET")
+	admin.HandleFunc("/admin/translations/sets", handlers.GetTranslationSets).Methods("GET")
+	admin.HandleFunc("/admin/translations/sets", handlers.CreateTranslationSet).Methods("POST")
+	admin.HandleFunc("/admin/translations/sets/diff", handlers.DiffTranslationSets).Methods("GET")
+	admin.HandleFunc("/admin/translations/sets/rollback", handlers.RollbackTranslationSet).Methods("POST")
+	admin.HandleFunc("/admin/translations/sets/{name}/promote", handlers.PromoteTranslationSet).Methods("POST")
+	admin.HandleFunc("/admin/translations/reload", handlers.ReloadTranslations).Methods("POST")
+	admin.HandleFunc("/admin/translations/direct", handlers.GetDirectTranslations).Methods("GET")
+	admin.HandleFunc("/admin/translations/direct", handlers.PutDirectTranslation).Methods("PUT")
+	admin.HandleFunc("/admin/translations/direct", handlers.DeleteDirectTranslation).Methods("DELETE")
+	admin.HandleFunc("/admin/translations/fallback", handlers.GetFallbackTranslations).Methods("GET")
+	admin.HandleFunc("/admin/translations/fallback", handlers.PutFallbackTranslation).Methods("PUT")
+	admin.HandleFunc("/admin/translations/fallback", handlers.DeleteFallbackTranslation).Methods("DELETE")
+	admin.HandleFunc("/admin/translations/code", handlers.GetCodeTranslations).Methods("GET")
+	admin.HandleFunc("/admin/translations/code", handlers.PutCodeTranslation).Methods("PUT")
+	admin.HandleFunc("/admin/translations/code", handlers.DeleteCodeTranslation).Methods("DELETE")
 
 	// Get server port from environment or use default
 	port := getEnv("PORT", "8080")
@@ -57,6 +176,270 @@ func main() {
 	log.Fatal(http.ListenAndServe(":"+port, router))
 }
 
+// openDatabases connects to the company databases, once, and exits when they stay unreachable
+func openDatabases(dbConfigs []db.Config) {
+	if len(db.Companies()) > 0 {
+		return
+	}
+	if err := db.InitDBs(dbConfigs); err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	log.Println("Database connected successfully")
+}
+
+// configureTranslations applies the translation settings shared by the server and the translation commands
+// The SQL translation store connects to the databases
+func configureTranslations(dbConfigs []db.Config) {
+	// Configure the translation resolution order, e.g. "code-override,direct,prefix"
+	if order := getEnv("TRANSLATION_ORDER", ""); order != "" {
+		if err := services.SetTranslationOrder(strings.Split(order, ",")); err != nil {
+			log.Fatalf("Invalid TRANSLATION_ORDER: %v", err)
+		}
+	}
+
+	// Translation store: JSON files in translate/ (default) or a database table with history
+	if getEnv("TRANSLATION_STORE", "file") == "sql" {
+		openDatabases(dbConfigs)
+		backend := initSQLTranslationBackend()
+		services.SetTranslationBackend(backend)
+		log.Printf("Translations are stored in %s", backend.Name())
+	}
+
+	// Tolerant name matching: case, whitespace and punctuation differences, optionally diacritics
+	services.SetNormalizationOptions(services.NormalizationOptions{
+		Enabled:        getEnvAsBool("TRANSLATION_NORMALIZE", true),
+		FoldDiacritics: getEnvAsBool("TRANSLATION_FOLD_DIACRITICS", true),
+	})
+}
+
+// runTranslationCommand runs a translation sheet or lint command without starting the server
+func runTranslationCommand(dbConfigs []db.Config, command string, args []string) {
+	configureTranslations(dbConfigs)
+	defer db.CloseDB()
+
+	switch command {
+	case "export-translations":
+		exportTranslations(dbConfigs, args)
+	case "import-translations":
+		importTranslations(args)
+	case "lint-translations":
+		lintTranslations(args)
+	}
+}
+
+// exportTranslations writes the dictionaries of a language to a CSV or XLSX sheet
+// Usage: resco export-translations -out translations.xlsx [-lang cn] [-company name] [-usage=false]
+func exportTranslations(dbConfigs []db.Config, args []string) {
+	flags := flag.NewFlagSet("export-translations", flag.ExitOnError)
+	lang := flags.String("lang", services.DefaultLanguage, "target language")
+	out := flags.String("out", "", "output file, .xlsx or .csv")
+	company := flags.String("company", "", "company database used for usage counts")
+	usage := flags.Bool("usage", true, "count the catalog items using each entry")
+	flags.Parse(args)
+
+	if *out == "" {
+		log.Fatal("export-translations: -out is required")
+	}
+
+	var conn *db.Connection
+	if *usage {
+		openDatabases(dbConfigs)
+		var err error
+		if conn, err = db.Get(*company); err != nil {
+			log.Fatalf("Invalid company: %v", err)
+		}
+	}
+
+	rows, err := services.ExportTranslations(context.Background(), conn, *lang)
+	if err != nil {
+		log.Fatalf("Translation export failed: %v", err)
+	}
+
+	file, err := os.Create(*out)
+	if err != nil {
+		log.Fatalf("Translation export failed: %v", err)
+	}
+	defer file.Close()
+
+	if err := services.WriteTranslationSheet(file, services.DetectSheetFormat(*out, nil), rows); err != nil {
+		log.Fatalf("Translation export failed: %v", err)
+	}
+	log.Printf("Exported %d translations (%s) to %s", len(rows), *lang, *out)
+}
+
+// importTranslations validates a translation sheet, prints its diff against the dictionaries and applies it
+// Usage: resco import-translations -file translations.xlsx [-lang cn] [-dry-run] [-author name]
+func importTranslations(args []string) {
+	flags := flag.NewFlagSet("import-translations", flag.ExitOnError)
+	lang := flags.String("lang", services.DefaultLanguage, "target language")
+	path := flags.String("file", "", "sheet to import, .xlsx or .csv")
+	dryRun := flags.Bool("dry-run", false, "show the changes without applying them")
+	author := flags.String("author", "cli", "author recorded in the translation history")
+	flags.Parse(args)
+
+	if *path == "" {
+		log.Fatal("import-translations: -file is required")
+	}
+
+	data, err := os.ReadFile(*path)
+	if err != nil {
+		log.Fatalf("Translation import failed: %v", err)
+	}
+
+	rows, err := services.ParseTranslationSheet(data, services.DetectSheetFormat(*path, data))
+	if err != nil {
+		log.Fatalf("Translation import failed: %v", err)
+	}
+
+	result, err := services.ImportTranslations(*lang, rows, *author, *dryRun)
+	for _, rowError := range result.Errors {
+		log.Printf("Invalid row: %s", rowError)
+	}
+	for _, change := range result.Added {
+		log.Printf("+ %s %s%q: %s", change.Scope, scopeKey(change.Key), change.Source, change.Target)
+	}
+	for _, change := range result.Changed {
+		log.Printf("~ %s %s%q: %s -> %s", change.Scope, scopeKey(change.Key), change.Source, change.OldTarget, change.Target)
+	}
+	if err != nil {
+		log.Fatalf("Translation import failed: %v", err)
+	}
+
+	state := "applied"
+	if !result.Applied {
+		state = "not applied"
+	}
+	log.Printf("Translation import (%s): %d added, %d changed, %d unchanged, %d skipped, %s",
+		*lang, len(result.Added), len(result.Changed), result.Unchanged, result.Skipped, state)
+}
+
+// lintTranslations prints the lint issues of the dictionaries of a language
+// Usage: resco lint-translations [-lang cn]
+func lintTranslations(args []string) {
+	flags := flag.NewFlagSet("lint-translations", flag.ExitOnError)
+	lang := flags.String("lang", services.DefaultLanguage, "target language")
+	flags.Parse(args)
+
+	report, err := services.LintTranslations(*lang)
+	if err != nil {
+		log.Fatalf("Translation lint failed: %v", err)
+	}
+	for _, issue := range report.Issues {
+		log.Printf("%s %s %s%s", issue.Check, issue.Scope, scopeKey(issue.Key), issue.Message)
+	}
+	log.Printf("Linted %d translations (%s): %d issues", report.Entries, *lang, len(report.Issues))
+	if len(report.Issues) > 0 {
+		os.Exit(1)
+	}
+}
+
+func scopeKey(key string) string {
+	if key == "" {
+		return ""
+	}
+	return "[" + key + "] "
+}
+
+// initSQLTranslationBackend opens the translation table in the TRANSLATION_COMPANY database, creating it if needed
+func initSQLTranslationBackend() *services.SQLTranslationBackend {
+	conn, err := db.Get(getEnv("TRANSLATION_COMPANY", ""))
+	if err != nil {
+		log.Fatalf("Invalid TRANSLATION_COMPANY: %v", err)
+	}
+
+	backend, err := services.NewSQLTranslationBackend(conn, getEnv("TRANSLATION_TABLE", services.DefaultTranslationTable))
+	if err != nil {
+		log.Fatalf("Invalid TRANSLATION_TABLE: %v", err)
+	}
+	if err := backend.EnsureSchema(context.Background()); err != nil {
+		log.Fatalf("Failed to prepare translation table: %v", err)
+	}
+	return backend
+}
+
+// migrateTranslations imports the JSON dictionaries of translate/ into the translation table and exits
+// Usage: resco migrate-translations [-author name]
+func migrateTranslations(backend *services.SQLTranslationBackend, args []string) {
+	flags := flag.NewFlagSet("migrate-translations", flag.ExitOnError)
+	author := flags.String("author", "migration", "author recorded in the translation history")
+	flags.Parse(args)
+
+	stats, err := backend.ImportFiles(context.Background(), *author)
+	if err != nil {
+		log.Fatalf("Translation import failed: %v", err)
+	}
+	for lang, langStats := range stats {
+		log.Printf("Imported translations (%s): %d created, %d updated, %d unchanged",
+			lang, langStats.Created, langStats.Updated, langStats.Unchanged)
+	}
+}
+
+// loadDBConfigs builds one database configuration per company listed in DB_COMPANIES
+// Each company can override the base DB_* settings with DB_<COMPANY>_* variables, the first company is the default
+func loadDBConfigs() []db.Config {
+	base := db.Config{
+		Name:     db.DefaultCompany,
+		Server:   getEnv("DB_SERVER", "localhost"),
+		Port:     getEnvAsInt("DB_PORT", 1433),
+		User:     getEnv("DB_USER", "sa"),
+		Password: getEnv("DB_PASSWORD", ""),
+		Database: getEnv("DB_DATABASE", "RESCO_2019"),
+		Schema:   getEnv("DB_SCHEMA", "dbo"),
+
+		MaxOpenConns:    getEnvAsInt("DB_MAX_OPEN_CONNS", 0),
+		MaxIdleConns:    getEnvAsInt("DB_MAX_IDLE_CONNS", 0),
+		ConnMaxLifetime: getEnvAsDuration("DB_CONN_MAX_LIFETIME", 0),
+		ConnMaxIdleTime: getEnvAsDuration("DB_CONN_MAX_IDLE_TIME", 0),
+		ConnectRetries:  getEnvAsInt("DB_CONNECT_RETRIES", 0),
+		RetryBackoff:    getEnvAsDuration("DB_RETRY_BACKOFF", time.Second),
+	}
+
+	companies := getEnv("DB_COMPANIES", "")
+	if companies == "" {
+		return []db.Config{base}
+	}
+
+	var configs []db.Config
+	for _, company := range strings.Split(companies, ",") {
+		company = strings.TrimSpace(company)
+		if company == "" {
+			continue
+		}
+
+		prefix := "DB_" + envKey(company) + "_"
+		configs = append(configs, db.Config{
+			Name:     company,
+			Server:   getEnv(prefix+"SERVER", base.Server),
+			Port:     getEnvAsInt(prefix+"PORT", base.Port),
+			User:     getEnv(prefix+"USER", base.User),
+			Password: getEnv(prefix+"PASSWORD", base.Password),
+			Database: getEnv(prefix+"DATABASE", base.Database),
+			Schema:   getEnv(prefix+"SCHEMA", base.Schema),
+
+			MaxOpenConns:    getEnvAsInt(prefix+"MAX_OPEN_CONNS", base.MaxOpenConns),
+			MaxIdleConns:    getEnvAsInt(prefix+"MAX_IDLE_CONNS", base.MaxIdleConns),
+			ConnMaxLifetime: getEnvAsDuration(prefix+"CONN_MAX_LIFETIME", base.ConnMaxLifetime),
+			ConnMaxIdleTime: getEnvAsDuration(prefix+"CONN_MAX_IDLE_TIME", base.ConnMaxIdleTime),
+			ConnectRetries:  getEnvAsInt(prefix+"CONNECT_RETRIES", base.ConnectRetries),
+			RetryBackoff:    getEnvAsDuration(prefix+"RETRY_BACKOFF", base.RetryBackoff),
+		})
+	}
+	return configs
+}
+
+// envKey converts a company name to the form used in environment variable names
+func envKey(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		if r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
 // Helper function to get environment variable with default value
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
@@ -77,4 +460,30 @@ func getEnvAsInt(key string, defaultValue int) int {
 		return defaultValue
 	}
 	return value
-}
\ No newline at end of file
+}
+
+// Helper function to get environment variable as duration (e.g. "30s", "5m") with default value
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+	value, err := time.ParseDuration(valueStr)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// Helper function to get environment variable as bool (true/false, 1/0) with default value
+func getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
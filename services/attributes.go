@@ -0,0 +1,332 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// SourceTemplate is the translation source of names translated through the size template of another entry
+const SourceTemplate = "template"
+
+// Material states parsed from item names
+const (
+	MaterialRaw          = "raw-material"
+	MaterialSemiFinished = "semi-finished"
+)
+
+// ItemAttributes are the structured attributes of an item name, e.g. "43x1.5 mm Gövde Borusu Hammadde"
+// Tubes with two dimensions also get their outer diameter and wall thickness
+type ItemAttributes struct {
+	Size          string    `json:"size,omitempty"`
+	Dimensions    []float64 `json:"dimensions,omitempty"`
+	Unit          string    `json:"unit,omitempty"`
+	OuterDiameter *float64  `json:"outer-diameter,omitempty"`
+	WallThickness *float64  `json:"wall-thickness,omitempty"`
+	Category      string    `json:"category,omitempty"`
+	Keywords      []string  `json:"keywords,omitempty"`
+	MaterialState string    `json:"material-state,omitempty"`
+	// Template is the name with the size replaced by {size} ("{size} mm Gövde Borusu Hammadde"), names sharing it differ only in size
+	Template string `json:"template,omitempty"`
+}
+
+// dimensionPattern finds sizes like "43x1.5 mm", "44.5x1.5mm" and "95x105x675 mm"
+var dimensionPattern = regexp.MustCompile(`\b(\d+(?:[.,]\d+)?(?:\s*[xX×]\s*\d+(?:[.,]\d+)?)+)(?:\s*(mm|cm|m)\b)?`)
+
+// sizeSeparator splits a size into its dimensions
+var sizeSeparator = regexp.MustCompile(`\s*[xX×]\s*`)
+
+// itemKeyword is a part name, in normalized form with its Turkish possessive forms, and its category
+type itemKeyword struct {
+	forms    []string
+	category string
+}
+
+// itemKeywords are the part names recognized in item names
+// The category of a name is the keyword ending last: "Toz Borusu Kapağı" is a cap, "Piston Valfi Somunu" a nut
+var itemKeywords = []itemKeyword{
+	{[]string{"govde borusu", "govde borulari"}, "body-tube"},
+	{[]string{"silindir borusu", "silindir borulari"}, "cylinder-tube"},
+	{[]string{"toz borusu", "toz borulari"}, "dust-tube"},
+	{[]string{"piston kolu"}, "piston-rod"},
+	{[]string{"piston"}, "piston"},
+	{[]string{"amortisor"}, "shock-absorber"},
+	{[]string{"helezon yay", "helezon yayi"}, "coil-spring"},
+	{[]string{"yay", "yayi"}, "spring"},
+	{[]string{"canak", "canagi"}, "spring-seat"},
+	{[]string{"pul", "pulu"}, "washer"},
+	{[]string{"somun", "somunu"}, "nut"},
+	{[]string{"civata", "civatasi", "saplama"}, "bolt"},
+	{[]string{"burc"}, "bushing"},
+	{[]string{"lastik", "lastigi"}, "rubber"},
+	{[]string{"braket", "braketi"}, "bracket"},
+	{[]string{"kapak", "kapagi"}, "cap"},
+	{[]string{"valf", "valfi"}, "valve"},
+	{[]string{"govde", "govdesi"}, "body"},
+	{[]string{"yuzuk", "yuzugu"}, "ring"},
+	{[]string{"segman"}, "circlip"},
+	{[]string{"kece"}, "seal"},
+	{[]string{"koruk", "korugu"}, "bellows"},
+	{[]string{"tampon", "tamponu"}, "bumper"},
+	{[]string{"kilavuz"}, "guide"},
+	{[]string{"tutucu"}, "holder"},
+	{[]string{"disk"}, "disc"},
+	{[]string{"boya"}, "paint"},
+	{[]string{"tiner"}, "thinner"},
+	{[]string{"sertlestirici"}, "hardener"},
+	{[]string{"yag", "yagi"}, "oil"},
+	{[]string{"paketleme", "kutu", "koli", "kolisi"}, "packaging"},
+}
+
+// materialKeywords mark the material state of an item
+var materialKeywords = []itemKeyword{
+	{[]string{"hammadde"}, MaterialRaw},
+	{[]string{"yari mamul"}, MaterialSemiFinished},
+}
+
+// ItemCategories returns the categories recognized by ParseItemName
+func ItemCategories() []string {
+	seen := make(map[string]bool)
+	var categories []string
+	for _, keyword := range itemKeywords {
+		if !seen[keyword.category] {
+			seen[keyword.category] = true
+			categories = append(categories, keyword.category)
+		}
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+// ParseItemName extracts the size, part category and material state of a Turkish item name
+// Returns nil when nothing is recognized
+func ParseItemName(name string) *ItemAttributes {
+	var attributes ItemAttributes
+
+	if sized, ok := parseSizedName(name); ok {
+		attributes.Dimensions = sized.dimensions
+		attributes.Size = formatSize(sized.dimensions)
+		attributes.Unit = sized.unit
+		attributes.Template = sized.template
+	}
+
+	// Keywords are matched on whole words of the normalized name
+	words := " " + strings.Join(strings.FieldsFunc(NormalizeName(name, true), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ") + " "
+
+	// Keywords inside a longer one ("piston" in "piston kolu") are dropped,
+	// the category is the keyword ending last: on the same end the one listed first, so "helezon yay" before "yay"
+	type keywordMatch struct {
+		form     string
+		category string
+		start    int
+		end      int
+	}
+	var matches []keywordMatch
+	for _, keyword := range itemKeywords {
+		if end, form := lastKeyword(words, keyword.forms); end >= 0 {
+			matches = append(matches, keywordMatch{form: form, category: keyword.category, start: end - len(form), end: end})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].end < matches[j].end
+	})
+	categoryEnd := -1
+	for i, match := range matches {
+		covered := false
+		for j, other := range matches {
+			if i != j && len(other.form) > len(match.form) && other.start <= match.start && match.end <= other.end {
+				covered = true
+				break
+			}
+		}
+		if covered {
+			continue
+		}
+		attributes.Keywords = append(attributes.Keywords, match.form)
+		if match.end > categoryEnd {
+			categoryEnd = match.end
+			attributes.Category = match.category
+		}
+	}
+	for _, keyword := range materialKeywords {
+		if end, _ := lastKeyword(words, keyword.forms); end >= 0 {
+			attributes.MaterialState = keyword.category
+		}
+	}
+
+	if strings.HasSuffix(attributes.Category, "-tube") && len(attributes.Dimensions) == 2 {
+		attributes.OuterDiameter = &attributes.Dimensions[0]
+		attributes.WallThickness = &attributes.Dimensions[1]
+	}
+
+	if attributes.Size == "" && attributes.Category == "" && attributes.MaterialState == "" {
+		return nil
+	}
+	return &attributes
+}
+
+// sizedName is a name split at its first size
+type sizedName struct {
+	template   string // the name with the size replaced by {size}
+	sizeText   string // the size as written, "44.5x1.5"
+	dimensions []float64
+	unit       string
+}
+
+// parseSizedName finds the first size of a name, false when it has none
+func parseSizedName(name string) (sizedName, bool) {
+	match := dimensionPattern.FindStringSubmatchIndex(name)
+	if match == nil {
+		return sizedName{}, false
+	}
+	dimensions, err := parseDimensions(name[match[2]:match[3]])
+	if err != nil {
+		return sizedName{}, false
+	}
+
+	sized := sizedName{sizeText: name[match[2]:match[3]], dimensions: dimensions}
+	template := "{size}"
+	if match[4] >= 0 {
+		sized.unit = name[match[4]:match[5]]
+		template += " " + sized.unit
+	}
+	sized.template = strings.TrimSpace(name[:match[0]] + template + name[match[1]:])
+	return sized, true
+}
+
+// buildSizeTemplates learns the translation of each size template from the direct entries,
+// "43x1.5 mm Gövde Borusu Hammadde": "43x1.5 mm 壳体管原材料" gives "{size} mm 壳体管原材料" for "{size} mm Gövde Borusu Hammadde"
+// Entries whose translation does not contain their size are skipped, when the entries of a template disagree
+// the most frequent translation wins (on a tie the first in sort order), so a single bad entry is outvoted
+// Templates are indexed by normalized name
+func buildSizeTemplates(translations map[string]string, foldDiacritics bool) map[string]string {
+	counts := make(map[string]map[string]int)
+	for source, target := range translations {
+		sized, ok := parseSizedName(source)
+		if !ok || !strings.Contains(target, sized.sizeText) {
+			continue
+		}
+		key := NormalizeName(sized.template, foldDiacritics)
+		if counts[key] == nil {
+			counts[key] = make(map[string]int)
+		}
+		counts[key][strings.Replace(target, sized.sizeText, "{size}", 1)]++
+	}
+
+	templates := make(map[string]string, len(counts))
+	for key, targets := range counts {
+		best, bestCount := "", 0
+		for target, count := range targets {
+			if count > bestCount || count == bestCount && target < best {
+				best, bestCount = target, count
+			}
+		}
+		templates[key] = best
+	}
+	return templates
+}
+
+// translateBySizeTemplate translates a name through the translation of its size template, filled with its own size
+// "45x1.5 mm Gövde Borusu Hammadde" is translated like "43x1.5 mm Gövde Borusu Hammadde" when only the latter has an entry
+// The caller must hold translationMutex
+func (d *dictionary) translateBySizeTemplate(text string) (string, bool) {
+	if len(d.sizeTemplates) == 0 {
+		return "", false
+	}
+	sized, ok := parseSizedName(strings.TrimSpace(text))
+	if !ok {
+		return "", false
+	}
+	target, exists := d.sizeTemplates[NormalizeName(sized.template, d.sizeTemplateFold)]
+	if !exists {
+		return "", false
+	}
+	return strings.Replace(target, "{size}", sized.sizeText, 1), true
+}
+
+// lastKeyword returns the end of the last occurrence of any form in words and the form, -1 when none occurs
+func lastKeyword(words string, forms []string) (int, string) {
+	end, found := -1, ""
+	for _, form := range forms {
+		if i := strings.LastIndex(words, " "+form+" "); i >= 0 && i+len(form) > end {
+			end, found = i+len(form), form
+		}
+	}
+	return end, found
+}
+
+// parseDimensions parses a size like "43x1.5" or "43 x 1,5"
+func parseDimensions(size string) ([]float64, error) {
+	parts := sizeSeparator.Split(strings.TrimSpace(size), -1)
+	dimensions := make([]float64, 0, len(parts))
+	for _, part := range parts {
+		value, err := strconv.ParseFloat(strings.Replace(part, ",", ".", 1), 64)
+		if err != nil || value <= 0 {
+			return nil, fmt.Errorf("invalid size: %s", size)
+		}
+		dimensions = append(dimensions, value)
+	}
+	return dimensions, nil
+}
+
+// ParseSize parses a size filter like "43x1.5" or "43x1.5mm" into its dimensions
+func ParseSize(size string) ([]float64, error) {
+	trimmed := strings.TrimSpace(size)
+	for _, unit := range []string{"mm", "cm", "m"} {
+		if strings.HasSuffix(trimmed, unit) {
+			trimmed = strings.TrimSpace(strings.TrimSuffix(trimmed, unit))
+			break
+		}
+	}
+	if trimmed == "" {
+		return nil, fmt.Errorf("invalid size: %s", size)
+	}
+	return parseDimensions(trimmed)
+}
+
+func formatSize(dimensions []float64) string {
+	parts := make([]string, len(dimensions))
+	for i, dimension := range dimensions {
+		parts[i] = strconv.FormatFloat(dimension, 'f', -1, 64)
+	}
+	return strings.Join(parts, "x")
+}
+
+// sameDimensions compares sizes, "43x1.5" equals "43.0x1.50"
+func sameDimensions(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
+
+// attachAttributes parses the parent and child names of every BOM line, before they are translated
+func attachAttributes(results []BOMResult) {
+	parsed := make(map[string]*ItemAttributes)
+	parse := func(name string) *ItemAttributes {
+		attributes, exists := parsed[name]
+		if !exists {
+			attributes = ParseItemName(name)
+			parsed[name] = attributes
+		}
+		return attributes
+	}
+
+	for i := range results {
+		results[i].ParentAttributes = parse(results[i].AD)
+		if results[i].SubItemName != nil {
+			results[i].ChildAttributes = parse(*results[i].SubItemName)
+		}
+	}
+}
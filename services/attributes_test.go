@@ -0,0 +1,133 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseItemName(t *testing.T) {
+	tests := []struct {
+		name          string
+		size          string
+		unit          string
+		outerDiameter float64
+		wallThickness float64
+		category      string
+		materialState string
+		template      string
+	}{
+		{"43x1.5 mm Gövde Borusu Hammadde", "43x1.5", "mm", 43, 1.5, "body-tube", MaterialRaw, "{size} mm Gövde Borusu Hammadde"},
+		{"44.5x1.5mm Toz Borusu Hammadde", "44.5x1.5", "mm", 44.5, 1.5, "dust-tube", MaterialRaw, "{size} mm Toz Borusu Hammadde"},
+		{"32 X 1,0 mm SİLİNDİR BORUSU", "32x1", "mm", 32, 1, "cylinder-tube", "", "{size} mm SİLİNDİR BORUSU"},
+		{"Baskılı Kutu - BINS - İç ölçü 95x105x675 mm", "95x105x675", "mm", 0, 0, "packaging", "", "Baskılı Kutu - BINS - İç ölçü {size} mm"},
+		{"Toz Borusu Kapağı", "", "", 0, 0, "cap", "", ""},
+		{"Piston Valfi Somunu", "", "", 0, 0, "nut", "", ""},
+		{"Piston Kolu Yarı Mamul", "", "", 0, 0, "piston-rod", MaterialSemiFinished, ""},
+		{"Helezon Yay", "", "", 0, 0, "coil-spring", "", ""},
+		{"Pistonlu Kapak", "", "", 0, 0, "cap", "", ""},
+	}
+
+	for _, tt := range tests {
+		attributes := ParseItemName(tt.name)
+		if attributes == nil {
+			t.Errorf("ParseItemName(%q) = nil", tt.name)
+			continue
+		}
+		if attributes.Size != tt.size || attributes.Unit != tt.unit || attributes.Category != tt.category ||
+			attributes.MaterialState != tt.materialState || attributes.Template != tt.template {
+			t.Errorf("ParseItemName(%q) = size %q unit %q category %q state %q template %q, want %q %q %q %q %q", tt.name,
+				attributes.Size, attributes.Unit, attributes.Category, attributes.MaterialState, attributes.Template,
+				tt.size, tt.unit, tt.category, tt.materialState, tt.template)
+		}
+
+		if tt.outerDiameter == 0 {
+			if attributes.OuterDiameter != nil || attributes.WallThickness != nil {
+				t.Errorf("ParseItemName(%q) has tube dimensions, want none", tt.name)
+			}
+			continue
+		}
+		if attributes.OuterDiameter == nil || *attributes.OuterDiameter != tt.outerDiameter ||
+			attributes.WallThickness == nil || *attributes.WallThickness != tt.wallThickness {
+			t.Errorf("ParseItemName(%q) tube dimensions = %v, %v, want %v, %v", tt.name,
+				attributes.OuterDiameter, attributes.WallThickness, tt.outerDiameter, tt.wallThickness)
+		}
+	}
+
+	for _, name := range []string{"", "Bilinmeyen Parça", "0x5 mm"} {
+		if attributes := ParseItemName(name); attributes != nil {
+			t.Errorf("ParseItemName(%q) = %+v, want nil", name, attributes)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		size string
+		want []float64
+	}{
+		{"43x1.5", []float64{43, 1.5}},
+		{"43x1.5mm", []float64{43, 1.5}},
+		{" 43 X 1,5 mm ", []float64{43, 1.5}},
+		{"95×105×675", []float64{95, 105, 675}},
+		{"2m", []float64{2}},
+		{"10cm", []float64{10}},
+		{"", nil},
+		{"mm", nil},
+		{"43x", nil},
+		{"0x1.5", nil},
+		{"abc", nil},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSize(tt.size)
+		if tt.want == nil {
+			if err == nil {
+				t.Errorf("ParseSize(%q) = %v, want error", tt.size, got)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseSize(%q) = %v, %v, want %v", tt.size, got, err, tt.want)
+		}
+	}
+}
+
+func TestSizeTemplates(t *testing.T) {
+	translations := map[string]string{
+		"43x1.5 mm Gövde Borusu Hammadde":  "43x1.5 mm 壳体管原材料",
+		"45x1.5 mm Gövde Borusu Hammadde":  "45x1.5 mm 壳体管原材料",
+		"32x1 mm Gövde Borusu Hammadde":    "32x1 mm mm 壳体管原材料",
+		"44.5x1.5mm Toz Borusu Hammadde":   "44.5x1.5 mm 防尘管原材料",
+		"30x2 mm Silindir Borusu Hammadde": "气缸管原材料",
+		"Baskılı Kutu - İç ölçü 95x105 mm": "印刷盒 - 内部尺寸 95x105 毫米",
+	}
+	d := &dictionary{sizeTemplates: buildSizeTemplates(translations, true), sizeTemplateFold: true}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		// The most frequent translation of a template wins over a single bad entry
+		{"47.5x2 mm Gövde Borusu Hammadde", "47.5x2 mm 壳体管原材料"},
+		{"47.5x2mm GÖVDE BORUSU HAMMADDE", "47.5x2 mm 壳体管原材料"},
+		{"50x2 mm Toz Borusu Hammadde", "50x2 mm 防尘管原材料"},
+		{"Baskılı Kutu - İç ölçü 90x100 mm", "印刷盒 - 内部尺寸 90x100 毫米"},
+		// Translations without their size teach nothing
+		{"33x1.5 mm Silindir Borusu Hammadde", ""},
+		{"47.5x2 cm Gövde Borusu Hammadde", ""},
+		{"Gövde Borusu Hammadde", ""},
+	}
+
+	for _, tt := range tests {
+		got, ok := d.translateBySizeTemplate(tt.name)
+		if tt.want == "" {
+			if ok {
+				t.Errorf("translateBySizeTemplate(%q) = %q, want no match", tt.name, got)
+			}
+			continue
+		}
+		if !ok || got != tt.want {
+			t.Errorf("translateBySizeTemplate(%q) = %q, %v, want %q", tt.name, got, ok, tt.want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
@@ -17,20 +18,44 @@ type BOMResult struct {
 	SubItemName     *string `json:"child-name"`
 	SubProSpec      string  `json:"sub_pro_spec"`
 	BOMRecKaynak0   float64 `json:"child-quantity"`
+	ChildUnit       *string `json:"child-unit,omitempty"`
 	Depth           int     `json:"depth"`
+	Position        string  `json:"position"`
+	Path            string  `json:"path"`
+	ParentAttributes *ItemAttributes `json:"parent-attributes,omitempty"`
+	ChildAttributes  *ItemAttributes `json:"child-attributes,omitempty"`
+	Provenance      *FieldProvenance `json:"provenance,omitempty"`
+	SiraNo          int     `json:"-"`
+	LineKey         string  `json:"-"`
 }
 
 type BOMResultCombined struct {
 	BOMRecCode      string  `json:"parent-number"`
 	AD              string  `json:"parent-name"`
-	ADChinese       string  `json:"parent-name-cn"`
+	ADChinese       string  `json:"parent-name-cn,omitempty"`
 	ParProSpec      string  `json:"par_pro_spec"`
 	BOMRecKaynakCode string `json:"child-number"`
 	SubItemName     *string `json:"child-name"`
-	SubItemNameChinese *string `json:"child-name-cn"`
+	SubItemNameChinese *string `json:"child-name-cn,omitempty"`
+	ParentNames     map[string]string `json:"parent-names,omitempty"`
+	ChildNames      map[string]string `json:"child-names,omitempty"`
 	SubProSpec      string  `json:"sub_pro_spec"`
 	BOMRecKaynak0   float64 `json:"child-quantity"`
+	ChildUnit       *string `json:"child-unit,omitempty"`
+	ChildUnits      map[string]string `json:"child-units,omitempty"`
 	Depth           int     `json:"depth"`
+	Position        string  `json:"position"`
+	Path            string  `json:"path"`
+	ParentAttributes *ItemAttributes `json:"parent-attributes,omitempty"`
+	ChildAttributes  *ItemAttributes `json:"child-attributes,omitempty"`
+	Provenance      map[string]FieldProvenance `json:"provenance,omitempty"`
+}
+
+// FieldProvenance tells where the translations of the names of a BOM line came from
+// Values: "direct", "code-override", "prefix:<prefix>", "rule:<id>" or "untranslated"
+type FieldProvenance struct {
+	ParentName string `json:"parent-name"`
+	ChildName  string `json:"child-name,omitempty"`
 }
 
 type BOMTotalResult struct {
@@ -42,10 +67,14 @@ type ProductCheckResult struct {
 	SequenceNumber int    `json:"sequence-number"`
 	Code           string `json:"code"`
 	Status         string `json:"status"` // "OK" or "NOT"
+	// Unit comparison, only when both the ERP (STOCK_UNIT_COLUMN) and Heihu have a unit
+	Unit           string `json:"unit,omitempty"`
+	HeihuUnit      string `json:"heihu-unit,omitempty"`
+	UnitMatch      *bool  `json:"unit-match,omitempty"`
 }
 
 // GetBOMByCode executes the recursive BOM query for a given item code
-func GetBOMByCode(itemCode string) ([]BOMResult, error) {
+func GetBOMByCode(ctx context.Context, conn *db.Connection, itemCode string) ([]BOMResult, error) {
 	// Read the SQL file
 	sqlContent, err := os.ReadFile("000.sql")
 	if err != nil {
@@ -55,8 +84,12 @@ func GetBOMByCode(itemCode string) ([]BOMResult, error) {
 	// Replace the hardcoded item code with the provided one
 	sqlQuery := strings.Replace(string(sqlContent), "'360004'", fmt.Sprintf("'%s'", itemCode), 1)
 
+	// Point the script at the company database
+	sqlQuery = strings.ReplaceAll(sqlQuery, "RESCO_2019.dbo.BOMU01T", conn.Table("BOMU01T"))
+	sqlQuery = strings.ReplaceAll(sqlQuery, "RESCO_2019.dbo.STOK00", conn.Table("STOK00"))
+
 	// Execute the query
-	rows, err := db.DB.Query(sqlQuery)
+	rows, err := conn.DB.QueryContext(ctx, sqlQuery)
 	if err != nil {
 		return nil, fmt.Errorf("error executing query: %v", err)
 	}
@@ -88,10 +121,8 @@ func GetBOMByCode(itemCode string) ([]BOMResult, error) {
 	return results, nil
 }
 
-// GetBOMByCodeParameterized executes the recursive BOM query using parameterized query
-func GetBOMByCodeParameterized(itemCode string) ([]BOMResult, error) {
-	// Split the SQL into executable batches
-	sqlBatch1 := `
+// bomQueryTemplate is the recursive BOM query, %[1]s is the BOMU01T table and %[2]s is the STOK00 table
+const bomQueryTemplate = `
 	IF OBJECT_ID('tempdb..#TempRecursiveResults') IS NOT NULL
 		DROP TABLE #TempRecursiveResults;
 
@@ -100,13 +131,13 @@ func GetBOMByCodeParameterized(itemCode string) ([]BOMResult, error) {
 
 	WITH RecursiveSearch AS (
 		SELECT EVRAKNO, TRNUM, SRNUM, BOMREC_SIRANO, BOMREC_CODE, BOMREC_KAYNAKCODE, BOMREC_KAYNAK0, TLOG_USERNAME, TLOG_LOGTARIH, TLOG_PSTATION, GK_2, 1 AS Depth
-		FROM RESCO_2019.dbo.BOMU01T
+		FROM %[1]s
 		WHERE BOMREC_CODE = @p1 AND BOMREC_INPUTTYPE='H'
 
 		UNION ALL
 
 		SELECT YT.EVRAKNO, YT.TRNUM, YT.SRNUM, YT.BOMREC_SIRANO, YT.BOMREC_CODE, YT.BOMREC_KAYNAKCODE, YT.BOMREC_KAYNAK0, YT.TLOG_USERNAME, YT.TLOG_LOGTARIH, YT.TLOG_PSTATION, YT.GK_2, RS.Depth + 1
-		FROM RESCO_2019.dbo.BOMU01T YT
+		FROM %[1]s YT
 		INNER JOIN RecursiveSearch RS ON YT.BOMREC_CODE = RS.BOMREC_KAYNAKCODE
 		WHERE RS.Depth < 10 AND YT.BOMREC_INPUTTYPE='H'
 	)
@@ -120,16 +151,16 @@ func GetBOMByCodeParameterized(itemCode string) ([]BOMResult, error) {
 	CAST('' AS NVARCHAR(255)) AS SubProSpec,
 	TRR.BOMREC_KAYNAK0,
 	TRR.Depth,
-	TRR.EVRAKNO, TRR.SRNUM
+	TRR.EVRAKNO, TRR.SRNUM, TRR.BOMREC_SIRANO
 	INTO #TempReco
 	FROM #TempRecursiveResults TRR
-	LEFT JOIN RESCO_2019.dbo.STOK00 RT ON TRR.BOMREC_CODE = RT.KOD
+	LEFT JOIN %[2]s RT ON TRR.BOMREC_CODE = RT.KOD
 	ORDER BY Depth ASC,EVRAKNO ASC, SRNUM ASC;
 
 	UPDATE T
 	SET T.SubItemName = R.AD
 	FROM #TempReco T
-	LEFT JOIN RESCO_2019.dbo.STOK00 R ON T.BOMREC_KAYNAKCODE = R.KOD;
+	LEFT JOIN %[2]s R ON T.BOMREC_KAYNAKCODE = R.KOD;
 
 	ALTER TABLE #TempReco
 	ALTER COLUMN BOMREC_CODE VARCHAR(24);
@@ -144,14 +175,22 @@ func GetBOMByCodeParameterized(itemCode string) ([]BOMResult, error) {
 		BOMREC_KAYNAKCODE = TRIM(BOMREC_KAYNAKCODE)
 	WHERE BOMREC_CODE IS NOT NULL OR AD IS NOT NULL OR SubItemName IS NOT NULL OR BOMREC_KAYNAKCODE IS NOT NULL;
 
-	SELECT BOMREC_CODE, AD, ParProSpec,BOMREC_KAYNAKCODE, SubItemName, SubProSpec,BOMREC_KAYNAK0,Depth FROM #TempReco
+	SELECT BOMREC_CODE, AD, ParProSpec,BOMREC_KAYNAKCODE, SubItemName, SubProSpec,BOMREC_KAYNAK0,Depth,
+	ISNULL(TRY_CAST(BOMREC_SIRANO AS INT), 0) AS SiraNo,
+	CONCAT(TRIM(CAST(EVRAKNO AS NVARCHAR(50))), '/', TRIM(CAST(SRNUM AS NVARCHAR(50)))) AS LineKey
+	FROM #TempReco
 	ORDER BY Depth ASC,EVRAKNO ASC, SRNUM ASC;
 
 	DROP TABLE #TempRecursiveResults;
 	DROP TABLE #TempReco;
 	`
 
-	rows, err := db.DB.Query(sqlBatch1, sql.Named("p1", itemCode))
+// GetBOMByCodeParameterized executes the recursive BOM query using parameterized query
+func GetBOMByCodeParameterized(ctx context.Context, conn *db.Connection, itemCode string) ([]BOMResult, error) {
+	// Qualify the tables with the company database and schema
+	sqlBatch1 := fmt.Sprintf(bomQueryTemplate, conn.Table("BOMU01T"), conn.Table("STOK00"))
+
+	rows, err := conn.DB.QueryContext(ctx, sqlBatch1, sql.Named("p1", itemCode))
 	if err != nil {
 		return nil, fmt.Errorf("error executing query: %v", err)
 	}
@@ -169,6 +208,8 @@ func GetBOMByCodeParameterized(itemCode string) ([]BOMResult, error) {
 			&result.SubProSpec,
 			&result.BOMRecKaynak0,
 			&result.Depth,
+			&result.SiraNo,
+			&result.LineKey,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("error scanning row: %v", err)
@@ -180,13 +221,24 @@ func GetBOMByCodeParameterized(itemCode string) ([]BOMResult, error) {
 		return nil, fmt.Errorf("error iterating rows: %v", err)
 	}
 
+	// Assign outline positions and paths from the root item
+	NumberBOM(itemCode, results)
+
+	// Add the units of the children when STOCK_UNIT_COLUMN is configured
+	if err := attachUnits(ctx, conn, results); err != nil {
+		return nil, err
+	}
+
+	// Parse sizes and part categories from the Turkish names
+	attachAttributes(results)
+
 	return results, nil
 }
 
 // GetBOMByCodeWithTranslation executes the recursive BOM query and applies Chinese translations
-func GetBOMByCodeWithTranslation(itemCode string) ([]BOMResult, error) {
+func GetBOMByCodeWithTranslation(ctx context.Context, conn *db.Connection, itemCode string) ([]BOMResult, error) {
 	// Get the BOM data
-	results, err := GetBOMByCodeParameterized(itemCode)
+	results, err := GetBOMByCodeParameterized(ctx, conn, itemCode)
 	if err != nil {
 		return nil, err
 	}
@@ -209,37 +261,73 @@ func GetBOMByCodeWithTranslation(itemCode string) ([]BOMResult, error) {
 	return translatedResults, nil
 }
 
-// GetBOMByCodeWithTranslationTracking executes the recursive BOM query and applies Chinese translations
-// Returns translated results and a slice of item codes that failed to translate
-func GetBOMByCodeWithTranslationTracking(itemCode string) ([]BOMResult, []string, error) {
-	// Get the BOM data
-	results, err := GetBOMByCodeParameterized(itemCode)
+// GetBOMByCodeWithTranslationTracking executes the recursive BOM query and applies translations
+// The first language of the query options is used (Chinese by default)
+// Query options are applied before translating, so filters match the Turkish names
+// Returns translated results, the translation report and the page info
+func GetBOMByCodeWithTranslationTracking(ctx context.Context, conn *db.Connection, itemCode string, opts BOMQueryOptions) ([]BOMResult, TranslationReport, PageInfo, error) {
+	// Load translations if not already loaded
+	err := LoadTranslations()
 	if err != nil {
-		return nil, nil, err
+		return nil, TranslationReport{}, PageInfo{}, fmt.Errorf("error loading translations: %v", err)
 	}
 
-	// Load translations if not already loaded
-	err = LoadTranslations()
+	lang := DefaultLanguage
+	if len(opts.Languages) > 0 {
+		lang = opts.Languages[0]
+	}
+	if err := checkLanguages([]string{lang}); err != nil {
+		return nil, TranslationReport{}, PageInfo{}, err
+	}
+
+	// Load the previewed translation set and the profile before querying the database
+	match, err := bomTranslationMatcher(opts, lang)
 	if err != nil {
-		return nil, nil, fmt.Errorf("error loading translations: %v", err)
+		return nil, TranslationReport{}, PageInfo{}, err
 	}
 
-	// Load fallback translations if not already loaded
-	err = LoadFallbackTranslations()
+	// Get the filtered BOM data
+	results, page, err := GetBOMByCodeFiltered(ctx, conn, itemCode, opts)
 	if err != nil {
-		return nil, nil, fmt.Errorf("error loading fallback translations: %v", err)
+		return nil, TranslationReport{}, PageInfo{}, err
 	}
 
-	// Apply translations and track failures
-	translatedResults, untranslatedCodes := ApplyTranslationsToBOMWithTracking(results)
+	// Apply translations and track failures, previews do not queue suggestions
+	translatedResults, report := applyTranslationsToBOM(results, lang, opts.Verbose, match)
+	if opts.TranslationSet == "" {
+		requestSuggestions(lang, report.reviewItems())
+	}
+
+	return translatedResults, report, page, nil
+}
 
-	return translatedResults, untranslatedCodes, nil
+// bomTranslationMatcher returns the lookup used to translate a BOM into lang:
+// the previewed translation set or the active translations, with the overrides of the profile on top
+// Lookups with the active translations are counted in the usage statistics, previews are not
+func bomTranslationMatcher(opts BOMQueryOptions, lang string) (func(turkishText string, itemCode string) TranslationMatch, error) {
+	set := opts.TranslationSet
+	if set == "" {
+		set = ActiveTranslationSet
+	}
+	match, err := translationSetMatcher(set, lang)
+	if err != nil {
+		return nil, err
+	}
+	if opts.TranslationProfile != "" {
+		if match, err = profileMatcher(opts.TranslationProfile, lang, match); err != nil {
+			return nil, err
+		}
+	}
+	if opts.TranslationSet == "" {
+		match = usageMatcher(lang, match)
+	}
+	return match, nil
 }
 
 // GetBOMByCodeCombined executes the recursive BOM query and returns both Turkish and Chinese
-func GetBOMByCodeCombined(itemCode string) ([]BOMResultCombined, error) {
+func GetBOMByCodeCombined(ctx context.Context, conn *db.Connection, itemCode string) ([]BOMResultCombined, error) {
 	// Get the BOM data
-	results, err := GetBOMByCodeParameterized(itemCode)
+	results, err := GetBOMByCodeParameterized(ctx, conn, itemCode)
 	if err != nil {
 		return nil, err
 	}
@@ -269,7 +357,12 @@ func GetBOMByCodeCombined(itemCode string) ([]BOMResultCombined, error) {
 			SubItemName:     result.SubItemName,
 			SubProSpec:      result.SubProSpec,
 			BOMRecKaynak0:   result.BOMRecKaynak0,
+			ChildUnit:       result.ChildUnit,
 			Depth:           result.Depth,
+			Position:        result.Position,
+			Path:            result.Path,
+			ParentAttributes: result.ParentAttributes,
+			ChildAttributes:  result.ChildAttributes,
 		}
 
 		// Translate child name if it exists
@@ -282,77 +375,121 @@ func GetBOMByCodeCombined(itemCode string) ([]BOMResultCombined, error) {
 	return combinedResults, nil
 }
 
-// GetBOMByCodeCombinedWithTracking executes the recursive BOM query and returns both Turkish and Chinese
-// Returns combined results and a slice of item codes that failed to translate
-func GetBOMByCodeCombinedWithTracking(itemCode string) ([]BOMResultCombined, []string, error) {
-	// Get the BOM data
-	results, err := GetBOMByCodeParameterized(itemCode)
+// GetBOMByCodeCombinedWithTracking executes the recursive BOM query and returns Turkish with one or more translations
+// Chinese is used when no languages are requested and also fills the "-cn" fields
+// Returns combined results, the translation report per language and the page info
+func GetBOMByCodeCombinedWithTracking(ctx context.Context, conn *db.Connection, itemCode string, opts BOMQueryOptions) ([]BOMResultCombined, map[string]*TranslationReport, PageInfo, error) {
+	// Load translations if not already loaded
+	err := LoadTranslations()
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, PageInfo{}, fmt.Errorf("error loading translations: %v", err)
 	}
 
-	// Load translations if not already loaded
-	err = LoadTranslations()
-	if err != nil {
-		return nil, nil, fmt.Errorf("error loading translations: %v", err)
+	languages := opts.Languages
+	if len(languages) == 0 {
+		languages = []string{DefaultLanguage}
+	}
+	if err := checkLanguages(languages); err != nil {
+		return nil, nil, PageInfo{}, err
 	}
 
-	// Load fallback translations if not already loaded
-	err = LoadFallbackTranslations()
+	// Use the previewed translation set or the active translations and the profile for every language
+	matchers := make(map[string]func(turkishText string, itemCode string) TranslationMatch)
+	for _, lang := range languages {
+		if matchers[lang], err = bomTranslationMatcher(opts, lang); err != nil {
+			return nil, nil, PageInfo{}, err
+		}
+	}
+
+	// Get the filtered BOM data
+	results, page, err := GetBOMByCodeFiltered(ctx, conn, itemCode, opts)
 	if err != nil {
-		return nil, nil, fmt.Errorf("error loading fallback translations: %v", err)
+		return nil, nil, PageInfo{}, err
 	}
 
-	// Track untranslated codes
-	untranslatedCodesMap := make(map[string]bool)
-	var untranslatedCodes []string
+	// Track untranslated and normalized matches per language
+	reports := make(map[string]*TranslationReport)
+	for _, lang := range languages {
+		reports[lang] = &TranslationReport{}
+	}
 
-	// Create combined results with both Turkish and Chinese
+	// Create combined results with Turkish and the requested languages
 	combinedResults := make([]BOMResultCombined, len(results))
 
 	for i, result := range results {
-		// Translate parent name
-		parentTranslated, parentSuccess := TranslateWithFallbackTracking(result.AD, result.BOMRecCode)
-		if !parentSuccess && result.BOMRecCode != "" {
-			if !untranslatedCodesMap[result.BOMRecCode] {
-				untranslatedCodesMap[result.BOMRecCode] = true
-				untranslatedCodes = append(untranslatedCodes, result.BOMRecCode)
-			}
-		}
-
 		combinedResults[i] = BOMResultCombined{
 			BOMRecCode:      result.BOMRecCode,
 			AD:              result.AD,
-			ADChinese:       parentTranslated,
 			ParProSpec:      result.ParProSpec,
 			BOMRecKaynakCode: result.BOMRecKaynakCode,
 			SubItemName:     result.SubItemName,
 			SubProSpec:      result.SubProSpec,
 			BOMRecKaynak0:   result.BOMRecKaynak0,
+			ChildUnit:       result.ChildUnit,
 			Depth:           result.Depth,
+			Position:        result.Position,
+			Path:            result.Path,
+			ParentAttributes: result.ParentAttributes,
+			ChildAttributes:  result.ChildAttributes,
+			ParentNames:     make(map[string]string),
+		}
+		if opts.Verbose {
+			combinedResults[i].Provenance = make(map[string]FieldProvenance)
 		}
 
-		// Translate child name if it exists
-		if result.SubItemName != nil && *result.SubItemName != "" {
-			childTranslated, childSuccess := TranslateWithFallbackTracking(*result.SubItemName, result.BOMRecKaynakCode)
-			combinedResults[i].SubItemNameChinese = &childTranslated
+		hasChildName := result.SubItemName != nil && *result.SubItemName != ""
+		if hasChildName {
+			combinedResults[i].ChildNames = make(map[string]string)
+		}
+		if result.ChildUnit != nil {
+			combinedResults[i].ChildUnits = make(map[string]string)
+		}
 
-			if !childSuccess && result.BOMRecKaynakCode != "" {
-				if !untranslatedCodesMap[result.BOMRecKaynakCode] {
-					untranslatedCodesMap[result.BOMRecKaynakCode] = true
-					untranslatedCodes = append(untranslatedCodes, result.BOMRecKaynakCode)
+		for _, lang := range languages {
+			// Translate parent name
+			parentMatch := matchers[lang](result.AD, result.BOMRecCode)
+			reports[lang].track(result.BOMRecCode, result.AD, parentMatch)
+			combinedResults[i].ParentNames[lang] = parentMatch.Text
+			if lang == DefaultLanguage {
+				combinedResults[i].ADChinese = parentMatch.Text
+			}
+			provenance := FieldProvenance{ParentName: parentMatch.Provenance()}
+
+			// Translate child name if it exists
+			if hasChildName {
+				childMatch := matchers[lang](*result.SubItemName, result.BOMRecKaynakCode)
+				reports[lang].track(result.BOMRecKaynakCode, *result.SubItemName, childMatch)
+				childTranslated := childMatch.Text
+				combinedResults[i].ChildNames[lang] = childTranslated
+				if lang == DefaultLanguage {
+					combinedResults[i].SubItemNameChinese = &childTranslated
 				}
+				provenance.ChildName = childMatch.Provenance()
+			}
+
+			// Translate the unit of the child, unmapped units stay Turkish
+			if result.ChildUnit != nil {
+				combinedResults[i].ChildUnits[lang], _ = TranslateValue(lang, ValueFieldUnit, *result.ChildUnit)
+			}
+
+			if opts.Verbose {
+				combinedResults[i].Provenance[lang] = provenance
 			}
 		}
 	}
 
-	return combinedResults, untranslatedCodes, nil
+	if opts.TranslationSet == "" {
+		for _, lang := range languages {
+			requestSuggestions(lang, reports[lang].reviewItems())
+		}
+	}
+	return combinedResults, reports, page, nil
 }
 
 // GetBOMTotal executes the recursive BOM query and returns unique codes with sequential numbers
-func GetBOMTotal(itemCode string) ([]BOMTotalResult, error) {
+func GetBOMTotal(ctx context.Context, conn *db.Connection, itemCode string) ([]BOMTotalResult, error) {
 	// Get the BOM data
-	results, err := GetBOMByCodeParameterized(itemCode)
+	results, err := GetBOMByCodeParameterized(ctx, conn, itemCode)
 	if err != nil {
 		return nil, err
 	}
@@ -389,19 +526,43 @@ func GetBOMTotal(itemCode string) ([]BOMTotalResult, error) {
 }
 
 // CheckProducts checks all BOM products against Heihu API with rate limiting
-func CheckProducts(itemCode string) ([]ProductCheckResult, error) {
+func CheckProducts(ctx context.Context, conn *db.Connection, itemCode string) ([]ProductCheckResult, error) {
 	// Step 1: Get all unique codes from BOM
-	bomTotal, err := GetBOMTotal(itemCode)
+	bomTotal, err := GetBOMTotal(ctx, conn, itemCode)
 	if err != nil {
 		return nil, fmt.Errorf("error getting BOM total: %v", err)
 	}
 
+	// Units are compared through the value mapping, Heihu uses Chinese units
+	codes := make([]string, len(bomTotal))
+	for i, item := range bomTotal {
+		codes[i] = item.Code
+	}
+	units, err := getItemUnits(ctx, conn, codes)
+	if err != nil {
+		return nil, fmt.Errorf("error getting units: %v", err)
+	}
+	if len(units) > 0 {
+		if err := LoadTranslations(); err != nil {
+			return nil, fmt.Errorf("error loading translations: %v", err)
+		}
+	}
+
 	// Step 2: Check each code against Heihu API with rate limiting
 	results := make([]ProductCheckResult, len(bomTotal))
 
 	for i, item := range bomTotal {
+		// Stop when the caller is gone or the request timed out
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("product check aborted after %d of %d products: %v", i, len(bomTotal), err)
+		}
+
 		// Query Heihu API
-		_, err := QueryHeihu(item.Code)
+		product, err := QueryHeihu(ctx, item.Code)
+		if err != nil && ctx.Err() != nil {
+			// The query failed because the request timed out, not because the product is missing
+			return nil, fmt.Errorf("product check aborted after %d of %d products: %v", i, len(bomTotal), ctx.Err())
+		}
 
 		// Determine status based on error
 		status := "OK"
@@ -419,12 +580,24 @@ func CheckProducts(itemCode string) ([]ProductCheckResult, error) {
 			SequenceNumber: item.SequenceNumber,
 			Code:           item.Code,
 			Status:         status,
+			Unit:           units[item.Code],
+		}
+		if err == nil {
+			results[i].HeihuUnit = HeihuUnit(product)
+		}
+		if results[i].Unit != "" && results[i].HeihuUnit != "" {
+			match := SameValue(DefaultLanguage, ValueFieldUnit, results[i].Unit, results[i].HeihuUnit)
+			results[i].UnitMatch = &match
 		}
 
 		// Rate limiting: Wait 100ms between requests to stay under 20 QPS limit
 		// 100ms delay = 10 requests/second (well under the 20 QPS limit)
 		if i < len(bomTotal)-1 {
-			time.Sleep(100 * time.Millisecond)
+			select {
+			case <-ctx.Done():
+				return nil, fmt.Errorf("product check aborted after %d of %d products: %v", i+1, len(bomTotal), ctx.Err())
+			case <-time.After(100 * time.Millisecond):
+			}
 		}
 	}
 
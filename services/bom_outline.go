@@ -0,0 +1,127 @@
+package services
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PathSeparator separates item codes in the BOM path from the root item
+const PathSeparator = " > "
+
+// bomLine is a distinct BOM line under a parent at a given depth
+// The recursive query repeats a line once per occurrence of its parent, rows holds those copies
+type bomLine struct {
+	siraNo int
+	rows   []int
+}
+
+type bomGroupKey struct {
+	parentCode string
+	depth      int
+}
+
+// NumberBOM assigns outline positions (1, 1.2, 1.2.3) and root paths to BOM lines in place
+// Siblings are numbered in BOMREC_SIRANO order, lines that cannot be reached from the root keep an empty position
+func NumberBOM(rootCode string, results []BOMResult) {
+	groups := make(map[bomGroupKey][]*bomLine)
+	linesByKey := make(map[string]*bomLine)
+
+	for i, result := range results {
+		parentCode := normalizeBOMCode(result.BOMRecCode)
+		if result.Depth == 1 {
+			// SQL Server matches the root code loosely, so all first level lines belong to the root
+			parentCode = ""
+		}
+		key := bomGroupKey{parentCode: parentCode, depth: result.Depth}
+
+		lineKey := result.LineKey
+		if lineKey == "" {
+			lineKey = "#" + strconv.Itoa(i)
+		}
+		lineKey = parentCode + "|" + strconv.Itoa(result.Depth) + "|" + lineKey
+
+		line, exists := linesByKey[lineKey]
+		if !exists {
+			line = &bomLine{siraNo: result.SiraNo}
+			linesByKey[lineKey] = line
+			groups[key] = append(groups[key], line)
+		}
+		line.rows = append(line.rows, i)
+	}
+
+	// Order siblings by BOMREC_SIRANO, keeping the query order for equal values
+	for _, lines := range groups {
+		sort.SliceStable(lines, func(i, j int) bool {
+			return lines[i].siraNo < lines[j].siraNo
+		})
+	}
+
+	used := make(map[*bomLine]int)
+
+	var visit func(parentCode string, depth int, position string, path string)
+	visit = func(parentCode string, depth int, position string, path string) {
+		for i, line := range groups[bomGroupKey{parentCode: parentCode, depth: depth}] {
+			// Each visit of the parent consumes the next copy of the line
+			if used[line] >= len(line.rows) {
+				continue
+			}
+			index := line.rows[used[line]]
+			used[line]++
+
+			linePosition := strconv.Itoa(i + 1)
+			if position != "" {
+				linePosition = position + "." + linePosition
+			}
+			linePath := path + PathSeparator + results[index].BOMRecKaynakCode
+
+			results[index].Position = linePosition
+			results[index].Path = linePath
+
+			visit(normalizeBOMCode(results[index].BOMRecKaynakCode), depth+1, linePosition, linePath)
+		}
+	}
+
+	visit("", 1, "", strings.TrimSpace(rootCode))
+}
+
+// ComparePositions compares two outline positions segment by segment
+// Empty positions sort after numbered ones
+func ComparePositions(a, b string) int {
+	if a == b {
+		return 0
+	}
+	if a == "" {
+		return 1
+	}
+	if b == "" {
+		return -1
+	}
+
+	partsA := strings.Split(a, ".")
+	partsB := strings.Split(b, ".")
+
+	for i := 0; i < len(partsA) && i < len(partsB); i++ {
+		numA, _ := strconv.Atoi(partsA[i])
+		numB, _ := strconv.Atoi(partsB[i])
+		if numA != numB {
+			if numA < numB {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	// A parent sorts before its children
+	if len(partsA) < len(partsB) {
+		return -1
+	}
+	if len(partsA) > len(partsB) {
+		return 1
+	}
+	return 0
+}
+
+func normalizeBOMCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
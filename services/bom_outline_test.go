@@ -0,0 +1,62 @@
+package services
+
+import "testing"
+
+func TestNumberBOM(t *testing.T) {
+	// Rows as returned by the recursive query: the line under SUB1 appears once per occurrence of SUB1
+	results := []BOMResult{
+		{BOMRecCode: "root", BOMRecKaynakCode: "SUB2", Depth: 1, SiraNo: 20, LineKey: "2"},
+		{BOMRecCode: "ROOT", BOMRecKaynakCode: "SUB1", Depth: 1, SiraNo: 10, LineKey: "1"},
+		{BOMRecCode: "ROOT", BOMRecKaynakCode: "SUB1", Depth: 1, SiraNo: 30, LineKey: "3"},
+		{BOMRecCode: "SUB1 ", BOMRecKaynakCode: "PART", Depth: 2, SiraNo: 10, LineKey: "4"},
+		{BOMRecCode: "SUB1", BOMRecKaynakCode: "PART", Depth: 2, SiraNo: 10, LineKey: "4"},
+		{BOMRecCode: "SUB2", BOMRecKaynakCode: "NUT", Depth: 2, SiraNo: 5, LineKey: "5"},
+		{BOMRecCode: "ORPHAN", BOMRecKaynakCode: "LOST", Depth: 3, SiraNo: 1, LineKey: "6"},
+	}
+
+	NumberBOM(" ROOT ", results)
+
+	want := []struct {
+		position string
+		path     string
+	}{
+		{"2", "ROOT > SUB2"},
+		{"1", "ROOT > SUB1"},
+		{"3", "ROOT > SUB1"},
+		{"1.1", "ROOT > SUB1 > PART"},
+		{"3.1", "ROOT > SUB1 > PART"},
+		{"2.1", "ROOT > SUB2 > NUT"},
+		{"", ""},
+	}
+	for i, w := range want {
+		if results[i].Position != w.position || results[i].Path != w.path {
+			t.Errorf("line %d (%s) = %q %q, want %q %q", i, results[i].BOMRecKaynakCode,
+				results[i].Position, results[i].Path, w.position, w.path)
+		}
+	}
+}
+
+func TestComparePositions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1", "1", 0},
+		{"1", "2", -1},
+		{"2", "10", -1},
+		{"10", "2", 1},
+		{"1.2", "1.10", -1},
+		{"1", "1.1", -1},
+		{"1.1", "1", 1},
+		{"1.2.3", "1.3", -1},
+		{"", "1", 1},
+		{"1", "", -1},
+		{"", "", 0},
+	}
+
+	for _, tt := range tests {
+		if got := ComparePositions(tt.a, tt.b); got != tt.want {
+			t.Errorf("ComparePositions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
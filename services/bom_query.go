@@ -0,0 +1,296 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"resco/db"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Supported sort keys for BOM endpoints. A leading "-" reverses the order.
+const (
+	SortByDepth    = "depth"
+	SortByCode     = "code"
+	SortByName     = "name"
+	SortByQuantity = "quantity"
+	SortByPosition = "position"
+)
+
+const maxBOMLimit = 1000
+
+// BOMQueryOptions holds the filtering, sorting and pagination options for BOM endpoints
+type BOMQueryOptions struct {
+	MinDepth     int
+	MaxDepth     int
+	CodePrefix   string
+	NameContains string
+	Size         []float64
+	Diameter     float64
+	Category     string
+	SortBy       string
+	Descending   bool
+	Limit        int
+	Offset       int
+	Languages    []string
+	Verbose      bool
+
+	// TranslationSet previews the translations of a stored set instead of the active ones
+	TranslationSet string
+	// TranslationProfile layers the overrides of a customer profile over the translations
+	TranslationProfile string
+}
+
+// PageInfo describes the page of BOM lines returned to the caller
+type PageInfo struct {
+	Total      int    `json:"total"`
+	NextCursor string `json:"next-cursor,omitempty"`
+}
+
+// ParseBOMQueryOptions reads BOM query options from URL query parameters
+// Supported parameters: minDepth, maxDepth, code, name, size, diameter, category, sort, limit, cursor, lang, verbose, translationSet, profile
+func ParseBOMQueryOptions(values url.Values) (BOMQueryOptions, error) {
+	var opts BOMQueryOptions
+	var err error
+
+	if opts.MinDepth, err = parseNonNegativeInt(values, "minDepth"); err != nil {
+		return opts, err
+	}
+	if opts.MaxDepth, err = parseNonNegativeInt(values, "maxDepth"); err != nil {
+		return opts, err
+	}
+	if opts.MaxDepth > 0 && opts.MinDepth > opts.MaxDepth {
+		return opts, fmt.Errorf("minDepth (%d) cannot be greater than maxDepth (%d)", opts.MinDepth, opts.MaxDepth)
+	}
+
+	opts.CodePrefix = strings.TrimSpace(values.Get("code"))
+	opts.NameContains = strings.TrimSpace(values.Get("name"))
+
+	// Filters on the attributes parsed from the child name, e.g. size=43x1.5, diameter=43, category=body-tube
+	if size := strings.TrimSpace(values.Get("size")); size != "" {
+		if opts.Size, err = ParseSize(size); err != nil {
+			return opts, err
+		}
+	}
+	if diameter := strings.TrimSpace(values.Get("diameter")); diameter != "" {
+		if opts.Diameter, err = strconv.ParseFloat(strings.Replace(diameter, ",", ".", 1), 64); err != nil || opts.Diameter <= 0 {
+			return opts, fmt.Errorf("invalid diameter: %s", diameter)
+		}
+	}
+	if category := strings.TrimSpace(values.Get("category")); category != "" {
+		if !containsString(ItemCategories(), category) {
+			return opts, fmt.Errorf("invalid category: %s (available: %s)", category, strings.Join(ItemCategories(), ", "))
+		}
+		opts.Category = category
+	}
+
+	sortBy := strings.TrimSpace(values.Get("sort"))
+	if strings.HasPrefix(sortBy, "-") {
+		opts.Descending = true
+		sortBy = sortBy[1:]
+	}
+	switch sortBy {
+	case "":
+		opts.SortBy = SortByDepth
+	case SortByDepth, SortByCode, SortByName, SortByQuantity, SortByPosition:
+		opts.SortBy = sortBy
+	case "depth-first":
+		opts.SortBy = SortByPosition
+	default:
+		return opts, fmt.Errorf("invalid sort key: %s", sortBy)
+	}
+
+	if opts.Limit, err = parseNonNegativeInt(values, "limit"); err != nil {
+		return opts, err
+	}
+	if opts.Limit > maxBOMLimit {
+		return opts, fmt.Errorf("limit cannot be greater than %d", maxBOMLimit)
+	}
+
+	if cursor := values.Get("cursor"); cursor != "" {
+		opts.Offset, err = decodeCursor(cursor)
+		if err != nil {
+			return opts, err
+		}
+	}
+
+	// Target languages, comma separated (e.g. lang=cn,en), repeated languages are listed once
+	for _, lang := range strings.Split(values.Get("lang"), ",") {
+		lang = strings.ToLower(strings.TrimSpace(lang))
+		if lang == "" || containsString(opts.Languages, lang) {
+			continue
+		}
+		if err := ValidateLanguage(lang); err != nil {
+			return opts, err
+		}
+		opts.Languages = append(opts.Languages, lang)
+	}
+
+	// Verbose mode adds the translation provenance of every name
+	if verbose := values.Get("verbose"); verbose != "" {
+		if opts.Verbose, err = strconv.ParseBool(verbose); err != nil {
+			return opts, fmt.Errorf("invalid verbose value: %s", verbose)
+		}
+	}
+
+	// Translation set to preview, e.g. translationSet=draft
+	if set := strings.TrimSpace(values.Get("translationSet")); set != "" {
+		if err := ValidateTranslationSetName(set); err != nil {
+			return opts, err
+		}
+		if set != ActiveTranslationSet {
+			opts.TranslationSet = set
+		}
+	}
+
+	// Customer translation profile, e.g. profile=acme
+	if profile := strings.TrimSpace(values.Get("profile")); profile != "" {
+		if err := ValidateTranslationProfileName(profile); err != nil {
+			return opts, err
+		}
+		opts.TranslationProfile = profile
+	}
+
+	return opts, nil
+}
+
+// FilterBOM applies depth, code and name filters, sorting and pagination to BOM results
+func FilterBOM(results []BOMResult, opts BOMQueryOptions) ([]BOMResult, PageInfo) {
+	var filtered []BOMResult
+	for _, result := range results {
+		if matchesBOMQuery(result, opts) {
+			filtered = append(filtered, result)
+		}
+	}
+
+	sortBOM(filtered, opts)
+
+	page := PageInfo{Total: len(filtered)}
+
+	if opts.Offset >= len(filtered) {
+		return []BOMResult{}, page
+	}
+	filtered = filtered[opts.Offset:]
+
+	if opts.Limit > 0 && len(filtered) > opts.Limit {
+		filtered = filtered[:opts.Limit]
+		page.NextCursor = encodeCursor(opts.Offset + opts.Limit)
+	}
+
+	return filtered, page
+}
+
+// GetBOMByCodeFiltered executes the recursive BOM query and applies the query options
+func GetBOMByCodeFiltered(ctx context.Context, conn *db.Connection, itemCode string, opts BOMQueryOptions) ([]BOMResult, PageInfo, error) {
+	results, err := GetBOMByCodeParameterized(ctx, conn, itemCode)
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	filtered, page := FilterBOM(results, opts)
+	return filtered, page, nil
+}
+
+// matchesBOMQuery reports whether a BOM line passes the depth, code, name and attribute filters
+// Code, name and attribute filters are applied to the child of the line
+func matchesBOMQuery(result BOMResult, opts BOMQueryOptions) bool {
+	if opts.MinDepth > 0 && result.Depth < opts.MinDepth {
+		return false
+	}
+	if opts.MaxDepth > 0 && result.Depth > opts.MaxDepth {
+		return false
+	}
+	if opts.CodePrefix != "" && !strings.HasPrefix(result.BOMRecKaynakCode, opts.CodePrefix) {
+		return false
+	}
+	if opts.NameContains != "" {
+		if result.SubItemName == nil {
+			return false
+		}
+		// Turkish case rules and folded diacritics, so "ISIL" finds "Işıl" and "govde" finds "Gövde"
+		if !strings.Contains(NormalizeName(*result.SubItemName, true), NormalizeName(opts.NameContains, true)) {
+			return false
+		}
+	}
+	if opts.Size != nil || opts.Diameter > 0 || opts.Category != "" {
+		attributes := result.ChildAttributes
+		if attributes == nil {
+			return false
+		}
+		if opts.Size != nil && !sameDimensions(attributes.Dimensions, opts.Size) {
+			return false
+		}
+		if opts.Diameter > 0 && (attributes.OuterDiameter == nil || !sameDimensions([]float64{*attributes.OuterDiameter}, []float64{opts.Diameter})) {
+			return false
+		}
+		if opts.Category != "" && attributes.Category != opts.Category {
+			return false
+		}
+	}
+	return true
+}
+
+// sortBOM sorts BOM lines in place, keeping the query order for equal keys
+func sortBOM(results []BOMResult, opts BOMQueryOptions) {
+	var less func(a, b BOMResult) bool
+
+	switch opts.SortBy {
+	case SortByCode:
+		less = func(a, b BOMResult) bool { return a.BOMRecKaynakCode < b.BOMRecKaynakCode }
+	case SortByName:
+		less = func(a, b BOMResult) bool { return childName(a) < childName(b) }
+	case SortByQuantity:
+		less = func(a, b BOMResult) bool { return a.BOMRecKaynak0 < b.BOMRecKaynak0 }
+	case SortByPosition:
+		// Depth-first order: each branch is listed top-to-bottom
+		less = func(a, b BOMResult) bool { return ComparePositions(a.Position, b.Position) < 0 }
+	default:
+		less = func(a, b BOMResult) bool { return a.Depth < b.Depth }
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if opts.Descending {
+			return less(results[j], results[i])
+		}
+		return less(results[i], results[j])
+	})
+}
+
+func childName(result BOMResult) string {
+	if result.SubItemName == nil {
+		return ""
+	}
+	return *result.SubItemName
+}
+
+func parseNonNegativeInt(values url.Values, key string) (int, error) {
+	raw := values.Get(key)
+	if raw == "" {
+		return 0, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid %s: %s", key, raw)
+	}
+	return value, nil
+}
+
+// encodeCursor returns an opaque cursor pointing at the given offset
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor: %s", cursor)
+	}
+	offset, err := strconv.Atoi(string(data))
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid cursor: %s", cursor)
+	}
+	return offset, nil
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// SourceComposed is the translation source of names composed from glossary terms
+const SourceComposed = "composed"
+
+// compactLanguages are written without spaces between words
+var compactLanguages = map[string]bool{
+	"cn": true,
+}
+
+// glossaryTranslationsFile returns the term glossary of a language, e.g. translate/glossary-tr-to-cn.json
+func glossaryTranslationsFile(lang string) string {
+	return filepath.Join(translationDir, "glossary-tr-to-"+lang+".json")
+}
+
+// readGlossaryFile reads a glossary of Turkish terms (one or more words) and indexes it by normalized term
+// Terms are always normalized, so "BORULU" and "borulu" are the same term; also returns the word count of the longest term
+func readGlossaryFile(path string, foldDiacritics bool) (map[string]string, int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error reading %s: %v", path, err)
+	}
+
+	entries, err := parseTranslations(path, data)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	glossary, _ := buildNormalizedIndex(entries, foldDiacritics)
+	maxWords := 0
+	for term := range glossary {
+		if words := len(strings.Fields(term)); words > maxWords {
+			maxWords = words
+		}
+	}
+	return glossary, maxWords, nil
+}
+
+// composeFromGlossary segments a name into glossary terms and joins their translations
+// At each word the longest term wins ("Piston Kolu" before "Piston"); words with digits and punctuation are kept
+// as written in the name ("HD15", "43X1.5"). Terms are matched on the normalized words.
+// A name with an unknown word or without any term is not composed. The caller must hold translationMutex
+func (d *dictionary) composeFromGlossary(lang string, text string) (string, bool) {
+	if len(d.glossary) == 0 {
+		return "", false
+	}
+
+	words := strings.Fields(text)
+	parts := make([]string, 0, len(words))
+	terms := 0
+	for i := 0; i < len(words); {
+		length := d.glossaryMaxWords
+		if length > len(words)-i {
+			length = len(words) - i
+		}
+		for ; length > 0; length-- {
+			if translated, exists := d.glossary[NormalizeName(strings.Join(words[i:i+length], " "), d.glossaryFold)]; exists {
+				parts = append(parts, translated)
+				terms++
+				break
+			}
+		}
+		if length > 0 {
+			i += length
+			continue
+		}
+
+		word := words[i]
+		if normalized := NormalizeName(word, d.glossaryFold); strings.IndexFunc(normalized, unicode.IsDigit) < 0 && !isPunctuationWord(normalized) {
+			return "", false
+		}
+		parts = append(parts, word)
+		i++
+	}
+	if terms == 0 {
+		return "", false
+	}
+
+	return joinTerms(lang, parts), true
+}
+
+// GlossaryTranslator suggests the glossary composition of a name for review
+// It is useful when SourceComposed is not in the translation order, or to turn composed names into reviewed entries
+type GlossaryTranslator struct{}
+
+func (GlossaryTranslator) Name() string {
+	return "glossary"
+}
+
+func (GlossaryTranslator) Suggest(ctx context.Context, lang string, text string, itemCode string) (string, bool, error) {
+	translationMutex.RLock()
+	defer translationMutex.RUnlock()
+
+	dict, exists := dictionaries[lang]
+	if !exists {
+		return "", false, nil
+	}
+
+	translated, ok := dict.composeFromGlossary(lang, text)
+	return translated, ok, nil
+}
+
+// joinTerms joins translated terms, for compact languages without spaces unless one side is a Latin letter or digit ("48x1 mm 防尘管")
+func joinTerms(lang string, parts []string) string {
+	if !compactLanguages[lang] {
+		return strings.Join(parts, " ")
+	}
+
+	var joined strings.Builder
+	for i, part := range parts {
+		if i > 0 && needsSpace(parts[i-1], part) {
+			joined.WriteString(" ")
+		}
+		joined.WriteString(part)
+	}
+	return joined.String()
+}
+
+func needsSpace(left, right string) bool {
+	last := []rune(left)[len([]rune(left))-1]
+	first := []rune(right)[0]
+	latinOrDigit := func(r rune) bool {
+		return unicode.IsDigit(r) || unicode.Is(unicode.Latin, r)
+	}
+	return latinOrDigit(last) || latinOrDigit(first)
+}
+
+func isPunctuationWord(word string) bool {
+	for _, r := range word {
+		if !unicode.IsPunct(r) {
+			return false
+		}
+	}
+	return true
+}
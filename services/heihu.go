@@ -2,19 +2,25 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"strings"
 )
 
 type HeihuRequest struct {
 	ProductCode string `json:"productCode"`
 }
 
+// heihuClient is shared by all Heihu calls, timeouts come from the request context
+var heihuClient = &http.Client{}
+
 // QueryHeihu queries the external Heihu API with the given item code
-func QueryHeihu(itemCode string) (map[string]interface{}, error) {
+// The call is cancelled when ctx is done
+func QueryHeihu(ctx context.Context, itemCode string) (map[string]interface{}, error) {
 	// Get configuration from environment variables
 	heihuLink := os.Getenv("HEIHU_LINK")
 	heihuSubLink := os.Getenv("HEIHU_SUB_LINK")
@@ -38,7 +44,7 @@ func QueryHeihu(itemCode string) (map[string]interface{}, error) {
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("error creating request: %v", err)
 	}
@@ -48,8 +54,7 @@ func QueryHeihu(itemCode string) (map[string]interface{}, error) {
 	req.Header.Set("X-Auth", xAuth)
 
 	// Send request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := heihuClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("error sending request: %v", err)
 	}
@@ -97,4 +102,28 @@ func QueryHeihu(itemCode string) (map[string]interface{}, error) {
 	}
 
 	return result, nil
-}
\ No newline at end of file
+}
+
+// HeihuUnit returns the unit of a product returned by QueryHeihu, empty if it has none
+// The field is "unit" unless HEIHU_UNIT_FIELD names another one; objects like {"name": "个"} are read by name
+func HeihuUnit(result map[string]interface{}) string {
+	product, ok := result["data"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	field := os.Getenv("HEIHU_UNIT_FIELD")
+	if field == "" {
+		field = "unit"
+	}
+
+	switch unit := product[field].(type) {
+	case string:
+		return strings.TrimSpace(unit)
+	case map[string]interface{}:
+		if name, ok := unit["name"].(string); ok {
+			return strings.TrimSpace(name)
+		}
+	}
+	return ""
+}
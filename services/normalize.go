@@ -0,0 +1,113 @@
+package services
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// NormalizationOptions controls how names are normalized for tolerant translation lookups
+type NormalizationOptions struct {
+	Enabled        bool
+	FoldDiacritics bool
+}
+
+// normalization is applied to dictionary keys and ERP names, set before loading the dictionaries
+var normalization = NormalizationOptions{Enabled: true, FoldDiacritics: true}
+
+// diacriticFolder maps Turkish (and common borrowed) letters to their ASCII base letters
+var diacriticFolder = strings.NewReplacer(
+	"ç", "c", "ğ", "g", "ö", "o", "ş", "s", "ü", "u",
+	"â", "a", "î", "i", "û", "u",
+)
+
+// SetNormalizationOptions configures name normalization
+// The normalized indexes are built when the dictionaries are loaded, so the options apply from the next reload
+func SetNormalizationOptions(opts NormalizationOptions) {
+	translationMutex.Lock()
+	defer translationMutex.Unlock()
+
+	normalization = opts
+}
+
+// NormalizeName normalizes a Turkish item name for tolerant matching
+// Steps: Turkish-aware lower casing with dotted and dotless i folded together, dash and
+// whitespace normalization, no spaces around punctuation, and optional diacritic folding
+func NormalizeName(name string, foldDiacritics bool) string {
+	// Turkish case mapping (İ -> i, I -> ı), then treat ı and i alike so "AMORTISÖR" matches "Amortisör"
+	name = strings.ToLowerSpecial(unicode.TurkishCase, name)
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r == 'ı':
+			return 'i'
+		case r == '̇':
+			// Combining dot above left over from non-Turkish lower casing of İ
+			return -1
+		case r == '‐' || r == '‑' || r == '‒' || r == '–' || r == '—':
+			return '-'
+		case unicode.IsSpace(r):
+			return ' '
+		}
+		return r
+	}, name)
+
+	if foldDiacritics {
+		name = diacriticFolder.Replace(name)
+	}
+
+	// Collapse whitespace and drop spaces around punctuation ("Amortisör , Kabin" -> "amortisör,kabin")
+	var b strings.Builder
+	pendingSpace := false
+	for _, r := range name {
+		if r == ' ' {
+			pendingSpace = true
+			continue
+		}
+		if pendingSpace {
+			last, _ := utf8.DecodeLastRuneInString(b.String())
+			if b.Len() > 0 && !isNamePunctuation(last) && !isNamePunctuation(r) {
+				b.WriteRune(' ')
+			}
+			pendingSpace = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// buildNormalizedIndex maps the normalized form of each key to its translation
+// Keys are visited in sorted order so the first of several colliding keys always wins,
+// the number of keys that lost a collision is returned
+func buildNormalizedIndex(entries map[string]string, foldDiacritics bool) (map[string]string, int) {
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	index := make(map[string]string, len(entries))
+	collisions := 0
+	for _, key := range keys {
+		normalized := NormalizeName(key, foldDiacritics)
+		if _, exists := index[normalized]; exists {
+			collisions++
+			continue
+		}
+		index[normalized] = entries[key]
+	}
+	return index, collisions
+}
+
+// currentNormalization returns the configured normalization options
+func currentNormalization() NormalizationOptions {
+	translationMutex.RLock()
+	defer translationMutex.RUnlock()
+
+	return normalization
+}
+
+// isNamePunctuation reports whether spaces next to r are insignificant ("HD 15 - 20" vs "HD 15-20")
+func isNamePunctuation(r rune) bool {
+	return unicode.IsPunct(r)
+}
@@ -0,0 +1,53 @@
+package services
+
+import "testing"
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		name           string
+		foldDiacritics bool
+		want           string
+	}{
+		{"Amortisör Yağı", true, "amortisor yagi"},
+		{"AMORTISÖR YAGI", true, "amortisor yagi"},
+		{"AMORTISÖR YAGI", false, "amortisör yagi"},
+		{"Amortisör Yağı", false, "amortisör yaği"},
+		{"İÇ ÖLÇÜ", true, "ic olcu"},
+		{"KILAVUZ", true, "kilavuz"},
+		{"kılavuz", true, "kilavuz"},
+		{"Kilavuz", true, "kilavuz"},
+		{"  Gövde   Borusu\t Hammadde ", true, "govde borusu hammadde"},
+		{"Amortisör , Kabin", true, "amortisor,kabin"},
+		{"HD 15 - 20", true, "hd 15-20"},
+		{"HD 15 – 20", true, "hd 15-20"},
+		{"HD 15—20", true, "hd 15-20"},
+		{"43x1.5 mm", true, "43x1.5 mm"},
+		{"", true, ""},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeName(tt.name, tt.foldDiacritics); got != tt.want {
+			t.Errorf("NormalizeName(%q, %v) = %q, want %q", tt.name, tt.foldDiacritics, got, tt.want)
+		}
+	}
+}
+
+func TestBuildNormalizedIndex(t *testing.T) {
+	entries := map[string]string{
+		"Amortisör Yağı": "减震器油",
+		"AMORTISOR YAGI": "减震器油 (ERP)",
+		"Somun":          "螺母",
+	}
+
+	index, collisions := buildNormalizedIndex(entries, true)
+	if collisions != 1 {
+		t.Errorf("collisions = %d, want 1", collisions)
+	}
+	// Keys are visited in sorted order, the first colliding key wins
+	if got := index["amortisor yagi"]; got != "减震器油 (ERP)" {
+		t.Errorf("index[amortisor yagi] = %q, want %q", got, "减震器油 (ERP)")
+	}
+	if got := index["somun"]; got != "螺母" {
+		t.Errorf("index[somun] = %q, want %q", got, "螺母")
+	}
+}
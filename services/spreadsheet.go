@@ -0,0 +1,373 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Spreadsheet formats supported for translation import and export
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// utf8BOM makes Excel open CSV files as UTF-8
+const utf8BOM = "\xEF\xBB\xBF"
+
+// xlsxStaticParts are the package parts of a workbook with a single sheet
+var xlsxStaticParts = []struct {
+	name    string
+	content string
+}{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Translations" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`},
+}
+
+// DetectSheetFormat guesses the format of an uploaded sheet from its file name, then from its content
+// XLSX files are zip archives and start with "PK"
+func DetectSheetFormat(filename string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".xlsx":
+		return FormatXLSX
+	case ".csv":
+		return FormatCSV
+	}
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		return FormatXLSX
+	}
+	return FormatCSV
+}
+
+// writeSpreadsheet writes rows (the first one being the header) as CSV or XLSX
+func writeSpreadsheet(w io.Writer, format string, rows [][]string) error {
+	switch format {
+	case FormatCSV:
+		if _, err := io.WriteString(w, utf8BOM); err != nil {
+			return err
+		}
+		writer := csv.NewWriter(w)
+		writer.WriteAll(rows)
+		return writer.Error()
+	case FormatXLSX:
+		return writeXLSX(w, rows)
+	}
+	return fmt.Errorf("unsupported format: %s", format)
+}
+
+// readSpreadsheet reads the rows of a CSV file or of the first sheet of an XLSX file
+func readSpreadsheet(data []byte, format string) ([][]string, error) {
+	switch format {
+	case FormatCSV:
+		reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte(utf8BOM))))
+		reader.FieldsPerRecord = -1
+		rows, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("error reading CSV: %v", err)
+		}
+		return rows, nil
+	case FormatXLSX:
+		return readXLSX(data)
+	}
+	return nil, fmt.Errorf("unsupported format: %s", format)
+}
+
+// writeXLSX writes a workbook with one sheet, all cells as inline strings
+func writeXLSX(w io.Writer, rows [][]string) error {
+	archive := zip.NewWriter(w)
+
+	for _, part := range xlsxStaticParts {
+		file, err := archive.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(file, part.content); err != nil {
+			return err
+		}
+	}
+
+	sheet, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	buf.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for i, row := range rows {
+		fmt.Fprintf(&buf, `<row r="%d">`, i+1)
+		for j, value := range row {
+			fmt.Fprintf(&buf, `<c r="%s%d" t="inlineStr"><is><t xml:space="preserve">`, columnName(j), i+1)
+			xml.EscapeText(&buf, []byte(value))
+			buf.WriteString(`</t></is></c>`)
+		}
+		buf.WriteString(`</row>`)
+	}
+	buf.WriteString(`</sheetData></worksheet>`)
+
+	if _, err := sheet.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+// xlsxText is a string item with optional rich text runs
+type xlsxText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var text strings.Builder
+	for _, run := range t.Runs {
+		text.WriteString(run.Text)
+	}
+	return text.String()
+}
+
+// maxXLSXPartSize caps the uncompressed size of each part read from a workbook, the upload limit only bounds the compressed file
+const maxXLSXPartSize = 64 << 20
+
+// maxXLSXColumn is the last column of a worksheet (XFD)
+const maxXLSXColumn = 16383
+
+// xlsxRelationships is a relationships part, e.g. xl/_rels/workbook.xml.rels
+type xlsxRelationships struct {
+	Items []struct {
+		ID     string `xml:"Id,attr"`
+		Type   string `xml:"Type,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// readXLSX reads the cell texts of the first sheet of a workbook
+func readXLSX(data []byte) ([][]string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("error reading XLSX: %v", err)
+	}
+
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	sheetPath, sharedStringsPath, err := xlsxFirstSheet(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var sharedStrings []string
+	if file, exists := files[sharedStringsPath]; exists {
+		content, err := readZipFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var table struct {
+			Items []xlsxText `xml:"si"`
+		}
+		if err := xml.Unmarshal(content, &table); err != nil {
+			return nil, fmt.Errorf("error reading XLSX shared strings: %v", err)
+		}
+		for _, item := range table.Items {
+			sharedStrings = append(sharedStrings, item.String())
+		}
+	}
+
+	file, exists := files[sheetPath]
+	if !exists {
+		return nil, fmt.Errorf("error reading XLSX: first worksheet %s not found", sheetPath)
+	}
+	sheetData, err := readZipFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Ref    string   `xml:"r,attr"`
+				Type   string   `xml:"t,attr"`
+				Value  string   `xml:"v"`
+				Inline xlsxText `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal(sheetData, &sheet); err != nil {
+		return nil, fmt.Errorf("error reading XLSX worksheet: %v", err)
+	}
+
+	rows := make([][]string, 0, len(sheet.Rows))
+	for _, sheetRow := range sheet.Rows {
+		var row []string
+		for i, cell := range sheetRow.Cells {
+			// Empty cells are omitted from the file, place each cell by its reference
+			column := i
+			if cell.Ref != "" {
+				column = columnIndex(cell.Ref)
+			}
+			if column < 0 || column > maxXLSXColumn {
+				return nil, fmt.Errorf("error reading XLSX: invalid cell reference %q", cell.Ref)
+			}
+			for len(row) <= column {
+				row = append(row, "")
+			}
+
+			switch cell.Type {
+			case "s":
+				index, err := strconv.Atoi(cell.Value)
+				if err != nil || index < 0 || index >= len(sharedStrings) {
+					return nil, fmt.Errorf("error reading XLSX: invalid shared string in cell %s", cell.Ref)
+				}
+				row[column] = sharedStrings[index]
+			case "inlineStr":
+				row[column] = cell.Inline.String()
+			default:
+				row[column] = cell.Value
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// xlsxFirstSheet resolves the paths of the first sheet listed in xl/workbook.xml and of the shared strings
+// through the workbook relationships, sheets are not always stored as xl/worksheets/sheet1.xml
+func xlsxFirstSheet(files map[string]*zip.File) (string, string, error) {
+	file, exists := files["xl/workbook.xml"]
+	if !exists {
+		return "", "", fmt.Errorf("error reading XLSX: xl/workbook.xml not found")
+	}
+	content, err := readZipFile(file)
+	if err != nil {
+		return "", "", err
+	}
+	var workbook struct {
+		Sheets []struct {
+			// The relationship id is namespaced (r:id), matching on the local name accepts both transitional and strict files
+			ID string `xml:"id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := xml.Unmarshal(content, &workbook); err != nil {
+		return "", "", fmt.Errorf("error reading XLSX workbook: %v", err)
+	}
+	if len(workbook.Sheets) == 0 || workbook.Sheets[0].ID == "" {
+		return "", "", fmt.Errorf("error reading XLSX: workbook has no sheets")
+	}
+
+	file, exists = files["xl/_rels/workbook.xml.rels"]
+	if !exists {
+		return "", "", fmt.Errorf("error reading XLSX: xl/_rels/workbook.xml.rels not found")
+	}
+	if content, err = readZipFile(file); err != nil {
+		return "", "", err
+	}
+	var rels xlsxRelationships
+	if err := xml.Unmarshal(content, &rels); err != nil {
+		return "", "", fmt.Errorf("error reading XLSX workbook relationships: %v", err)
+	}
+
+	sheetPath, sharedStringsPath := "", ""
+	for _, rel := range rels.Items {
+		if rel.ID == workbook.Sheets[0].ID {
+			sheetPath = xlsxPartPath(rel.Target)
+		}
+		if strings.HasSuffix(rel.Type, "/sharedStrings") {
+			sharedStringsPath = xlsxPartPath(rel.Target)
+		}
+	}
+	if sheetPath == "" {
+		return "", "", fmt.Errorf("error reading XLSX: no relationship for sheet %s", workbook.Sheets[0].ID)
+	}
+	return sheetPath, sharedStringsPath, nil
+}
+
+// xlsxPartPath resolves a relationship target of the workbook, relative to xl/ unless absolute
+func xlsxPartPath(target string) string {
+	if strings.HasPrefix(target, "/") {
+		return strings.TrimPrefix(path.Clean(target), "/")
+	}
+	return path.Join("xl", target)
+}
+
+// readZipFile reads a workbook part of at most maxXLSXPartSize bytes
+// The declared size is checked first and the read is capped, as the header of a crafted archive can lie
+func readZipFile(file *zip.File) ([]byte, error) {
+	if file.UncompressedSize64 > maxXLSXPartSize {
+		return nil, fmt.Errorf("error reading XLSX: %s is larger than %d MB", file.Name, maxXLSXPartSize>>20)
+	}
+	reader, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("error reading XLSX: %v", err)
+	}
+	defer reader.Close()
+	content, err := io.ReadAll(io.LimitReader(reader, maxXLSXPartSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("error reading XLSX: %v", err)
+	}
+	if len(content) > maxXLSXPartSize {
+		return nil, fmt.Errorf("error reading XLSX: %s is larger than %d MB", file.Name, maxXLSXPartSize>>20)
+	}
+	return content, nil
+}
+
+// columnName returns the spreadsheet column name of a zero-based index (0 -> A, 26 -> AA)
+func columnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// columnIndex returns the zero-based column of a cell reference (B7 -> 1)
+// Returns -1 for references that are not upper case letters followed by a row number, or past the last column
+func columnIndex(ref string) int {
+	letters := 0
+	index := 0
+	for letters < len(ref) && ref[letters] >= 'A' && ref[letters] <= 'Z' {
+		index = index*26 + int(ref[letters]-'A'+1)
+		letters++
+		if index-1 > maxXLSXColumn {
+			return -1
+		}
+	}
+	if letters == 0 {
+		return -1
+	}
+	row, err := strconv.Atoi(ref[letters:])
+	if err != nil || row < 1 || ref[letters] == '+' {
+		return -1
+	}
+	return index - 1
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testWorkbook zips workbook parts, the first sheet being xl/worksheets/<sheetName>
+func testWorkbook(t *testing.T, sheetName string, sheetData string, sharedStrings string) []byte {
+	t.Helper()
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Çeviriler" sheetId="3" r:id="rId7"/><sheet name="Other" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId7" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="/xl/worksheets/` + sheetName + `"/>
+<Relationship Id="rId9" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/sharedStrings" Target="sharedStrings.xml"/>
+</Relationships>`,
+		"xl/worksheets/sheet1.xml":   `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData><row r="1"><c r="A1" t="inlineStr"><is><t>wrong sheet</t></is></c></row></sheetData></worksheet>`,
+		"xl/worksheets/" + sheetName: `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` + sheetData + `</sheetData></worksheet>`,
+		"xl/sharedStrings.xml":       `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` + sharedStrings + `</sst>`,
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range parts {
+		file, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		file.Write([]byte(content))
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestSpreadsheetRoundTrip(t *testing.T) {
+	rows := [][]string{
+		{"source", "target", "note"},
+		{"Amortisör Yağı", "减震器油", ""},
+		{"<Somun> & \"Pul\"", "螺母, 垫圈", "line\nbreak"},
+	}
+
+	for _, format := range []string{FormatCSV, FormatXLSX} {
+		var buf bytes.Buffer
+		if err := writeSpreadsheet(&buf, format, rows); err != nil {
+			t.Fatalf("%s: writeSpreadsheet: %v", format, err)
+		}
+		if got := DetectSheetFormat("upload", buf.Bytes()); got != format {
+			t.Errorf("%s: DetectSheetFormat = %s", format, got)
+		}
+		got, err := readSpreadsheet(buf.Bytes(), format)
+		if err != nil {
+			t.Fatalf("%s: readSpreadsheet: %v", format, err)
+		}
+		if !reflect.DeepEqual(got, rows) {
+			t.Errorf("%s: readSpreadsheet = %q, want %q", format, got, rows)
+		}
+	}
+}
+
+func TestReadXLSX(t *testing.T) {
+	sharedStrings := `<si><t>source</t></si><si><r><t>Amortisör </t></r><r><t>Yağı</t></r></si>`
+
+	tests := []struct {
+		name      string
+		sheetData string
+		want      [][]string
+		err       string
+	}{
+		{
+			name: "shared strings and gaps",
+			sheetData: `<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="inlineStr"><is><t>target</t></is></c></row>` +
+				`<row r="2"><c r="B2" t="s"><v>1</v></c><c r="C2"><v>42</v></c></row>`,
+			want: [][]string{{"source", "", "target"}, {"", "Amortisör Yağı", "42"}},
+		},
+		{
+			name:      "cells without references",
+			sheetData: `<row><c t="inlineStr"><is><t>a</t></is></c><c t="inlineStr"><is><t>b</t></is></c></row>`,
+			want:      [][]string{{"a", "b"}},
+		},
+		{
+			name:      "last column",
+			sheetData: `<row r="1"><c r="XFD1"><v>1</v></c></row>`,
+			want:      [][]string{append(make([]string, maxXLSXColumn), "1")},
+		},
+		{name: "past the last column", sheetData: `<row r="1"><c r="XFE1"><v>1</v></c></row>`, err: "invalid cell reference"},
+		{name: "long reference", sheetData: `<row r="1"><c r="AAAAAAAAAAAAAAAAAAAAAAAAAA1"><v>1</v></c></row>`, err: "invalid cell reference"},
+		{name: "lower case reference", sheetData: `<row r="1"><c r="a1"><v>1</v></c></row>`, err: "invalid cell reference"},
+		{name: "no row number", sheetData: `<row r="1"><c r="A"><v>1</v></c></row>`, err: "invalid cell reference"},
+		{name: "signed row number", sheetData: `<row r="1"><c r="A+1"><v>1</v></c></row>`, err: "invalid cell reference"},
+		{name: "unknown shared string", sheetData: `<row r="1"><c r="A1" t="s"><v>5</v></c></row>`, err: "invalid shared string"},
+	}
+
+	for _, tt := range tests {
+		got, err := readXLSX(testWorkbook(t, "renamed.xml", tt.sheetData, sharedStrings))
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: error = %v, want %q", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: rows = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := readXLSX([]byte("source,target\n")); err == nil {
+		t.Error("readXLSX of a CSV file succeeded, want error")
+	}
+}
+
+func TestColumnIndex(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want int
+	}{
+		{"A1", 0},
+		{"B7", 1},
+		{"Z10", 25},
+		{"AA1", 26},
+		{"XFD1048576", maxXLSXColumn},
+		{"XFE1", -1},
+		{"1", -1},
+		{"A0", -1},
+		{"A-1", -1},
+		{"b2", -1},
+		{"", -1},
+	}
+
+	for _, tt := range tests {
+		if got := columnIndex(tt.ref); got != tt.want {
+			t.Errorf("columnIndex(%q) = %d, want %d", tt.ref, got, tt.want)
+		}
+		if tt.want >= 0 {
+			if name := columnName(tt.want); !strings.HasPrefix(tt.ref, name) {
+				t.Errorf("columnName(%d) = %q, want prefix of %q", tt.want, name, tt.ref)
+			}
+		}
+	}
+}
@@ -2,61 +2,602 @@ package services
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 )
 
-var (
-	translations map[string]string
+const translationDir = "translate"
+
+// DefaultLanguage is the target language used when none is requested
+const DefaultLanguage = "cn"
+
+// Translation sources, also used as names in the resolution order
+// (SourceRule is in translation_rules.go, SourceTemplate in attributes.go, SourceComposed in glossary.go)
+const (
+	SourceCodeOverride = "code-override"
+	SourceDirect       = "direct"
+	SourcePrefix       = "prefix"
+)
+
+// DefaultTranslationOrder is the resolution order used unless configured otherwise
+var DefaultTranslationOrder = []string{SourceCodeOverride, SourceDirect, SourcePrefix, SourceRule, SourceTemplate, SourceComposed}
+
+// ErrUnknownLanguage is returned when no dictionary exists for a requested language
+var ErrUnknownLanguage = errors.New("unknown language")
+
+// languagePattern restricts language codes to safe file name parts (cn, en, ru, pt-br)
+var languagePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})?$`)
+
+// dictionary holds the Turkish to target language translations of one language
+type dictionary struct {
+	translations         map[string]string
 	fallbackTranslations map[string]map[string]string
+	codeTranslations     map[string]string
+	rules                []TranslationRule
+
+	// Distinct fallback prefix lengths, longest first
+	fallbackPrefixLengths []int
+	shadowedPrefixes      []PrefixShadow
+
+	// Normalized name indexes, nil when normalization is disabled
+	normalized             *NormalizationOptions
+	normalizedTranslations map[string]string
+	normalizedFallback     map[string]map[string]string
+	normalizedCollisions   int
+
+	// Term glossary indexed by normalized term, used for composed translations and suggestions
+	glossary         map[string]string
+	glossaryFold     bool
+	glossaryMaxWords int
+
+	// Translations of size templates learned from the direct entries, indexed by normalized template
+	sizeTemplates    map[string]string
+	sizeTemplateFold bool
+
+	// Entries indexed by normalized translation, for reverse lookups
+	reverse []reverseEntry
+
+	// Enumerated values (units) by field, as in the file and indexed by normalized value
+	valueEntries map[string]map[string]string
+	values       map[string]map[string]string
+}
+
+// ProvenanceUntranslated is the provenance of names without a translation
+const ProvenanceUntranslated = "untranslated"
+
+// TranslationMatch describes the result of a translation lookup
+// Profile names the customer profile that provided the translation, empty for the base dictionary
+type TranslationMatch struct {
+	Text       string
+	Source     string
+	Prefix     string
+	Rule       string
+	Profile    string
+	Normalized bool
+}
+
+// Provenance returns where the translation came from: "direct", "code-override", "prefix:8010", "rule:<id>" or "untranslated"
+// Translations of a customer profile are prefixed with the profile, e.g. "profile:acme:direct"
+func (m TranslationMatch) Provenance() string {
+	if m.Profile != "" && m.Source != "" {
+		base := m
+		base.Profile = ""
+		return "profile:" + m.Profile + ":" + base.Provenance()
+	}
+	switch m.Source {
+	case "":
+		return ProvenanceUntranslated
+	case SourcePrefix:
+		return SourcePrefix + ":" + m.Prefix
+	case SourceRule:
+		return SourceRule + ":" + m.Rule
+	}
+	return m.Source
+}
+
+// UntranslatedItem is an item whose name has no translation
+type UntranslatedItem struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+// TranslationReport collects the item codes of a BOM that were not translated,
+// that were only translated after normalizing their names or by composing glossary terms, and the rules that translated them
+type TranslationReport struct {
+	Untranslated []UntranslatedItem
+	Normalized   []string
+	Composed     []UntranslatedItem
+	Rules        map[string]string
+
+	seen map[string]bool
+}
+
+// track records the outcome of translating name, the name of itemCode
+func (r *TranslationReport) track(itemCode string, name string, match TranslationMatch) {
+	if itemCode == "" {
+		return
+	}
+	if match.Source == SourceRule {
+		if r.Rules == nil {
+			r.Rules = make(map[string]string)
+		}
+		r.Rules[itemCode] = match.Rule
+	}
+	if match.Source != "" && match.Source != SourceComposed && !match.Normalized {
+		return
+	}
+	if r.seen == nil {
+		r.seen = make(map[string]bool)
+	}
+
+	kind := "normalized:"
+	switch match.Source {
+	case "":
+		kind = "untranslated:"
+	case SourceComposed:
+		kind = "composed:"
+	}
+	if r.seen[kind+itemCode] {
+		return
+	}
+	r.seen[kind+itemCode] = true
+
+	switch match.Source {
+	case "":
+		r.Untranslated = append(r.Untranslated, UntranslatedItem{Code: itemCode, Name: name})
+	case SourceComposed:
+		r.Composed = append(r.Composed, UntranslatedItem{Code: itemCode, Name: name})
+	default:
+		r.Normalized = append(r.Normalized, itemCode)
+	}
+}
+
+// reviewItems returns the items whose names need a reviewed translation: untranslated and composed ones
+func (r *TranslationReport) reviewItems() []UntranslatedItem {
+	return append(append([]UntranslatedItem(nil), r.Untranslated...), r.Composed...)
+}
+
+var (
+	dictionaries map[string]*dictionary
+	translationOrder = DefaultTranslationOrder
 	translationMutex sync.RWMutex
 	translationsLoaded bool
 	fallbackLoaded bool
+	lastReload TranslationReloadResult
 )
 
-// LoadTranslations loads the Turkish to Chinese translations from the JSON file
+// DictionaryStats describes the entries loaded for one language
+type DictionaryStats struct {
+	DirectEntries    int `json:"direct-entries"`
+	FallbackPrefixes int `json:"fallback-prefixes"`
+	FallbackEntries  int `json:"fallback-entries"`
+	CodeOverrides    int `json:"code-overrides"`
+	Rules            int `json:"rules"`
+	GlossaryTerms    int `json:"glossary-terms"`
+	SizeTemplates    int `json:"size-templates"`
+	Values           int `json:"values"`
+	// ShadowedPrefixEntries counts fallback entries hidden by the same name under a longer prefix
+	ShadowedPrefixEntries int `json:"shadowed-prefix-entries"`
+	// NormalizedCollisions counts keys that normalize to the same name as another key and are unreachable by tolerant matching
+	NormalizedCollisions int `json:"normalized-collisions"`
+}
+
+// TranslationReloadResult describes a successful load of the translation dictionaries
+type TranslationReloadResult struct {
+	Languages map[string]DictionaryStats            `json:"languages"`
+	Profiles  map[string]map[string]DictionaryStats `json:"profiles,omitempty"`
+	LoadedAt  time.Time                             `json:"loaded-at"`
+	Duration  string                                `json:"duration"`
+	Warnings  []string                              `json:"warnings,omitempty"`
+}
+
+// directTranslationsFile returns the direct dictionary file of a language, e.g. translate/tr-to-cn.json
+func directTranslationsFile(lang string) string {
+	return filepath.Join(translationDir, "tr-to-"+lang+".json")
+}
+
+// fallbackTranslationsFile returns the prefix fallback dictionary file of a language
+func fallbackTranslationsFile(lang string) string {
+	return filepath.Join(translationDir, "fallback-tr-to-"+lang+".json")
+}
+
+// codeTranslationsFile returns the item code override file of a language
+func codeTranslationsFile(lang string) string {
+	return filepath.Join(translationDir, "code-tr-to-"+lang+".json")
+}
+
+// ValidateLanguage checks that a language code is well formed
+func ValidateLanguage(lang string) error {
+	if !languagePattern.MatchString(lang) {
+		return fmt.Errorf("%w: %q", ErrUnknownLanguage, lang)
+	}
+	return nil
+}
+
+// LoadTranslations loads the translation dictionaries if they are not loaded yet
 func LoadTranslations() error {
-	translationMutex.Lock()
-	defer translationMutex.Unlock()
+	translationMutex.RLock()
+	loaded := translationsLoaded
+	translationMutex.RUnlock()
+
+	if loaded {
+		return nil
+	}
+
+	_, err := ReloadTranslations()
+	return err
+}
+
+// LoadFallbackTranslations loads the item code prefix fallback translations if they are not loaded yet
+func LoadFallbackTranslations() error {
+	translationMutex.RLock()
+	loaded := fallbackLoaded
+	translationMutex.RUnlock()
 
-	if translationsLoaded {
+	if loaded {
 		return nil
 	}
 
-	data, err := os.ReadFile("translate/tr-to-cn.json")
+	_, err := ReloadTranslations()
+	return err
+}
+
+// ReloadTranslations reads and validates the dictionary files of every language and customer profile and swaps them in atomically
+// A language exists when translate/tr-to-{lang}.json exists, its fallback, item code override, rule, glossary and value files are optional
+// If any file is invalid, the current translations are kept and an error is returned
+func ReloadTranslations() (TranslationReloadResult, error) {
+	start := time.Now()
+
+	backend := activeTranslationBackend()
+	languages, err := backend.Languages()
 	if err != nil {
-		return err
+		return TranslationReloadResult{}, err
+	}
+	if len(languages) == 0 {
+		return TranslationReloadResult{}, fmt.Errorf("no translation dictionaries found in %s", backend.Name())
 	}
 
-	err = json.Unmarshal(data, &translations)
+	newDictionaries := make(map[string]*dictionary)
+	result := TranslationReloadResult{Languages: make(map[string]DictionaryStats)}
+	opts := currentNormalization()
+
+	for _, lang := range languages {
+		dict, err := readDictionary(backend, lang, opts)
+		if err != nil {
+			return TranslationReloadResult{}, err
+		}
+		newDictionaries[lang] = dict
+		result.Languages[lang] = dict.stats()
+		for _, shadow := range dict.shadowedPrefixes {
+			result.Warnings = append(result.Warnings, lang+": "+shadow.String())
+		}
+	}
+
+	// Customer profiles are always files, layered over the dictionaries of the backend
+	newProfiles, warnings, err := readProfiles(languages, opts)
 	if err != nil {
-		return err
+		return TranslationReloadResult{}, err
+	}
+	result.Warnings = append(result.Warnings, warnings...)
+	for name, profileDictionaries := range newProfiles {
+		if result.Profiles == nil {
+			result.Profiles = make(map[string]map[string]DictionaryStats)
+		}
+		result.Profiles[name] = make(map[string]DictionaryStats)
+		for lang, dict := range profileDictionaries {
+			result.Profiles[name][lang] = dict.stats()
+		}
 	}
 
+	result.LoadedAt = time.Now()
+	result.Duration = time.Since(start).String()
+
+	translationMutex.Lock()
+	dictionaries = newDictionaries
+	profiles = newProfiles
 	translationsLoaded = true
-	return nil
+	fallbackLoaded = true
+	lastReload = result
+	translationMutex.Unlock()
+	setDictionaries.clear()
+
+	return result, nil
 }
 
-// LoadFallbackTranslations loads the fallback translations based on first 4 digits
-func LoadFallbackTranslations() error {
-	translationMutex.Lock()
-	defer translationMutex.Unlock()
+// discoverLanguages returns the languages that have a direct dictionary file
+func discoverLanguages() ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(translationDir, "tr-to-*.json"))
+	if err != nil {
+		return nil, err
+	}
 
-	if fallbackLoaded {
-		return nil
+	var languages []string
+	for _, path := range paths {
+		lang := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), "tr-to-"), ".json")
+		if ValidateLanguage(lang) != nil {
+			continue
+		}
+		languages = append(languages, lang)
 	}
+	sort.Strings(languages)
+	return languages, nil
+}
+
+// translationReader reads the dictionaries of a language, implemented by the backends and by translation sets
+type translationReader interface {
+	ReadDirect(lang string) (map[string]string, string, error)
+	ReadFallback(lang string) (map[string]map[string]string, string, error)
+	ReadCode(lang string) (map[string]string, string, error)
+}
 
-	data, err := os.ReadFile("translate/fallback-tr-to-cn.json")
+// readDictionary reads and validates all dictionaries of a language from the backend, and its rule file
+// When normalization is enabled, the normalized name indexes are built as well
+func readDictionary(backend translationReader, lang string, opts NormalizationOptions) (*dictionary, error) {
+	translations, _, err := backend.ReadDirect(lang)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	err = json.Unmarshal(data, &fallbackTranslations)
+	fallbackTranslations, _, err := backend.ReadFallback(lang)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	fallbackLoaded = true
+	codeTranslations, _, err := backend.ReadCode(lang)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []TranslationRule
+	if fileExists(rulesTranslationsFile(lang)) {
+		rules, err = readRulesFile(rulesTranslationsFile(lang))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var glossary map[string]string
+	var glossaryMaxWords int
+	if fileExists(glossaryTranslationsFile(lang)) {
+		glossary, glossaryMaxWords, err = readGlossaryFile(glossaryTranslationsFile(lang), opts.FoldDiacritics)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var valueEntries, values map[string]map[string]string
+	if fileExists(valueTranslationsFile(lang)) {
+		valueEntries, values, err = readValuesFile(valueTranslationsFile(lang))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	dict := &dictionary{
+		translations:         translations,
+		fallbackTranslations: fallbackTranslations,
+		codeTranslations:     codeTranslations,
+		rules:                rules,
+
+		fallbackPrefixLengths: prefixLengths(fallbackTranslations),
+		shadowedPrefixes:      FindShadowedPrefixes(fallbackTranslations),
+
+		glossary:         glossary,
+		glossaryFold:     opts.FoldDiacritics,
+		glossaryMaxWords: glossaryMaxWords,
+
+		sizeTemplates:    buildSizeTemplates(translations, opts.FoldDiacritics),
+		sizeTemplateFold: opts.FoldDiacritics,
+
+		reverse: buildReverseIndex(translations, fallbackTranslations, codeTranslations),
+
+		valueEntries: valueEntries,
+		values:       values,
+	}
+
+	if opts.Enabled {
+		dict.normalized = &opts
+		var collisions int
+		dict.normalizedTranslations, collisions = buildNormalizedIndex(translations, opts.FoldDiacritics)
+		dict.normalizedCollisions += collisions
+
+		dict.normalizedFallback = make(map[string]map[string]string)
+		for prefix, prefixTranslations := range fallbackTranslations {
+			dict.normalizedFallback[prefix], collisions = buildNormalizedIndex(prefixTranslations, opts.FoldDiacritics)
+			dict.normalizedCollisions += collisions
+		}
+	}
+
+	return dict, nil
+}
+
+func (d *dictionary) stats() DictionaryStats {
+	stats := DictionaryStats{
+		DirectEntries:    len(d.translations),
+		FallbackPrefixes: len(d.fallbackTranslations),
+		CodeOverrides:    len(d.codeTranslations),
+		Rules:            len(d.rules),
+		GlossaryTerms:    len(d.glossary),
+		SizeTemplates:    len(d.sizeTemplates),
+
+		ShadowedPrefixEntries: len(d.shadowedPrefixes),
+
+		NormalizedCollisions: d.normalizedCollisions,
+	}
+	for _, prefixTranslations := range d.fallbackTranslations {
+		stats.FallbackEntries += len(prefixTranslations)
+	}
+	for _, values := range d.valueEntries {
+		stats.Values += len(values)
+	}
+	return stats
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// Languages returns the languages with a loaded dictionary
+func Languages() []string {
+	translationMutex.RLock()
+	defer translationMutex.RUnlock()
+
+	languages := make([]string, 0, len(dictionaries))
+	for lang := range dictionaries {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// HasLanguage reports whether a dictionary is loaded for the language
+func HasLanguage(lang string) bool {
+	translationMutex.RLock()
+	defer translationMutex.RUnlock()
+
+	_, exists := dictionaries[lang]
+	return exists
+}
+
+// checkLanguages returns an error for the first language without a loaded dictionary
+func checkLanguages(languages []string) error {
+	for _, lang := range languages {
+		if !HasLanguage(lang) {
+			return fmt.Errorf("%w: %s (available: %s)", ErrUnknownLanguage, lang, strings.Join(Languages(), ", "))
+		}
+	}
+	return nil
+}
+
+// LastTranslationReload returns the result of the latest reload
+func LastTranslationReload() TranslationReloadResult {
+	translationMutex.RLock()
+	defer translationMutex.RUnlock()
+
+	return lastReload
+}
+
+// WatchTranslations polls the translation backend and reloads the dictionaries when they change
+// Invalid files are logged and the previous translations stay active
+func WatchTranslations(interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	var once sync.Once
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		lastSignature := activeTranslationBackend().Signature()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				signature := activeTranslationBackend().Signature()
+				if signature == lastSignature {
+					continue
+				}
+				lastSignature = signature
+
+				result, err := ReloadTranslations()
+				if err != nil {
+					log.Printf("Translation reload failed, keeping previous translations: %v", err)
+					continue
+				}
+				log.Printf("Translations reloaded: %d languages (%s)", len(result.Languages), result.Duration)
+				for _, warning := range result.Warnings {
+					log.Printf("Translation warning: %s", warning)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// translationFilesSignature returns the names and modification times of the dictionary and profile files
+// Any edit, new file or removed file changes the signature
+func translationFilesSignature() string {
+	var signature strings.Builder
+	paths, _ := filepath.Glob(filepath.Join(translationDir, "*tr-to-*.json"))
+	profilePaths, _ := filepath.Glob(filepath.Join(translationProfilesDir(), "*", "*tr-to-*.json"))
+	for _, path := range append(paths, profilePaths...) {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(&signature, "%s:%d;", path, info.ModTime().UnixNano())
+	}
+	return signature.String()
+}
+
+// parseTranslations parses and validates a flat dictionary (direct translations or item code overrides)
+func parseTranslations(path string, data []byte) (map[string]string, error) {
+	var entries map[string]string
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+	if err := validateTranslations(path, entries); err != nil {
+		return nil, err
+	}
+	if entries == nil {
+		entries = make(map[string]string)
+	}
+	return entries, nil
+}
+
+// parseFallbackTranslations parses and validates a prefix fallback dictionary
+func parseFallbackTranslations(path string, data []byte) (map[string]map[string]string, error) {
+	var entries map[string]map[string]string
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+	if err := validateFallbackTranslations(path, entries); err != nil {
+		return nil, err
+	}
+	if entries == nil {
+		entries = make(map[string]map[string]string)
+	}
+	return entries, nil
+}
+
+// validateTranslations rejects entries with an empty source or target
+func validateTranslations(origin string, entries map[string]string) error {
+	for turkishText, translatedText := range entries {
+		if turkishText == "" || translatedText == "" {
+			return fmt.Errorf("invalid entry in %s: empty text for %q", origin, turkishText)
+		}
+	}
+	return nil
+}
+
+// validateFallbackTranslations rejects empty prefixes and entries with an empty source or target
+func validateFallbackTranslations(origin string, entries map[string]map[string]string) error {
+	for prefix, prefixTranslations := range entries {
+		if prefix == "" {
+			return fmt.Errorf("invalid entry in %s: empty prefix", origin)
+		}
+		for turkishText, translatedText := range prefixTranslations {
+			if turkishText == "" || translatedText == "" {
+				return fmt.Errorf("invalid entry in %s: empty text for %q under prefix %s", origin, turkishText, prefix)
+			}
+		}
+	}
 	return nil
 }
 
@@ -66,60 +607,157 @@ func Translate(turkishText string) string {
 	translationMutex.RLock()
 	defer translationMutex.RUnlock()
 
-	if chineseText, exists := translations[turkishText]; exists {
-		return chineseText
+	if dict, exists := dictionaries[DefaultLanguage]; exists {
+		if chineseText, exists := dict.translations[turkishText]; exists {
+			return chineseText
+		}
 	}
 	return turkishText
 }
 
-// TranslateWithFallback returns the Chinese translation using fallback logic
-// First tries direct translation, then uses first 4 digits of itemCode for fallback
-func TranslateWithFallback(turkishText string, itemCode string) string {
+// SetTranslationOrder configures the order in which translation sources are tried
+// Valid sources are "code-override", "direct", "prefix", "rule", "template" and "composed", each at most once
+func SetTranslationOrder(order []string) error {
+	seen := make(map[string]bool)
+	for _, source := range order {
+		switch source {
+		case SourceCodeOverride, SourceDirect, SourcePrefix, SourceRule, SourceTemplate, SourceComposed:
+		default:
+			return fmt.Errorf("unknown translation source: %s", source)
+		}
+		if seen[source] {
+			return fmt.Errorf("duplicate translation source: %s", source)
+		}
+		seen[source] = true
+	}
+	if len(order) == 0 {
+		return fmt.Errorf("translation order cannot be empty")
+	}
+
+	translationMutex.Lock()
+	translationOrder = append([]string(nil), order...)
+	translationMutex.Unlock()
+	return nil
+}
+
+// TranslationOrder returns the order in which translation sources are tried
+func TranslationOrder() []string {
 	translationMutex.RLock()
 	defer translationMutex.RUnlock()
 
-	// Try direct translation first
-	if chineseText, exists := translations[turkishText]; exists {
-		return chineseText
+	return append([]string(nil), translationOrder...)
+}
+
+// lookupTranslation tries each translation source of a language in the configured order
+// Names are matched exactly first, then by their normalized form when normalization is enabled
+// The caller must hold translationMutex
+func lookupTranslation(lang string, turkishText string, itemCode string) TranslationMatch {
+	dict, exists := dictionaries[lang]
+	if !exists {
+		return TranslationMatch{Text: turkishText}
 	}
+	return dict.lookup(lang, turkishText, itemCode)
+}
 
-	// If not found and itemCode is provided, try fallback based on first 4 digits
-	if itemCode != "" && len(itemCode) >= 4 {
-		prefix := itemCode[:4]
-		if prefixTranslations, exists := fallbackTranslations[prefix]; exists {
-			if chineseText, exists := prefixTranslations[turkishText]; exists {
-				return chineseText
+// lookup tries each translation source of the dictionary in the configured order
+// The caller must hold translationMutex
+func (d *dictionary) lookup(lang string, turkishText string, itemCode string) TranslationMatch {
+	return d.lookupInOrder(translationOrder, lang, turkishText, itemCode)
+}
+
+// lookupInOrder tries each translation source of the dictionary in the given order
+func (d *dictionary) lookupInOrder(order []string, lang string, turkishText string, itemCode string) TranslationMatch {
+	// Normalized lazily, most names match exactly
+	normalizedText := ""
+	normalize := func() string {
+		if normalizedText == "" {
+			normalizedText = NormalizeName(turkishText, d.normalized.FoldDiacritics)
+		}
+		return normalizedText
+	}
+
+	for _, source := range order {
+		switch source {
+		case SourceCodeOverride:
+			// Exact item code, so parts sharing a Turkish name can have different translations
+			if translated, exists := d.codeTranslations[strings.TrimSpace(itemCode)]; exists && itemCode != "" {
+				return TranslationMatch{Text: translated, Source: SourceCodeOverride}
+			}
+		case SourceDirect:
+			if translated, exists := d.translations[turkishText]; exists {
+				return TranslationMatch{Text: translated, Source: SourceDirect}
+			}
+			if d.normalized != nil {
+				if translated, exists := d.normalizedTranslations[normalize()]; exists {
+					return TranslationMatch{Text: translated, Source: SourceDirect, Normalized: true}
+				}
+			}
+		case SourcePrefix:
+			// Fallback based on the longest item code prefix with an entry for the name
+			if match, ok := d.lookupPrefixTranslation(turkishText, itemCode, normalize); ok {
+				return match
+			}
+		case SourceRule:
+			// Pattern rules, e.g. one rule for every tube dimension
+			if translated, ruleID, ok := matchRule(d.rules, turkishText, itemCode); ok {
+				return TranslationMatch{Text: translated, Source: SourceRule, Rule: ruleID}
+			}
+		case SourceTemplate:
+			// Another size of a name with an entry, e.g. a tube in a new diameter
+			if translated, ok := d.translateBySizeTemplate(turkishText); ok {
+				return TranslationMatch{Text: translated, Source: SourceTemplate}
+			}
+		case SourceComposed:
+			// Candidate composed from glossary terms, reported for review
+			if translated, ok := d.composeFromGlossary(lang, turkishText); ok {
+				return TranslationMatch{Text: translated, Source: SourceComposed}
 			}
 		}
 	}
+	return TranslationMatch{Text: turkishText}
+}
 
-	// Return original text if no translation found
-	return turkishText
+// TranslateWithFallback returns the Chinese translation using fallback logic
+// Sources are tried in the configured order: item code override, direct translation, longest itemCode prefix, rules
+func TranslateWithFallback(turkishText string, itemCode string) string {
+	translated, _ := TranslateWithSource(turkishText, itemCode)
+	return translated
 }
 
 // TranslateWithFallbackTracking returns the Chinese translation using fallback logic
 // Returns the translated text and a boolean indicating if translation was successful
 func TranslateWithFallbackTracking(turkishText string, itemCode string) (string, bool) {
-	translationMutex.RLock()
-	defer translationMutex.RUnlock()
+	translated, provenance := TranslateWithProvenance(turkishText, itemCode)
+	return translated, provenance != ProvenanceUntranslated
+}
 
-	// Try direct translation first
-	if chineseText, exists := translations[turkishText]; exists {
-		return chineseText, true
-	}
+// TranslateWithProvenance returns the Chinese translation and where it came from
+// e.g. "direct", "code-override", "prefix:8010", "rule:<id>" or "untranslated"
+func TranslateWithProvenance(turkishText string, itemCode string) (string, string) {
+	match := MatchTranslation(DefaultLanguage, turkishText, itemCode)
+	return match.Text, match.Provenance()
+}
 
-	// If not found and itemCode is provided, try fallback based on first 4 digits
-	if itemCode != "" && len(itemCode) >= 4 {
-		prefix := itemCode[:4]
-		if prefixTranslations, exists := fallbackTranslations[prefix]; exists {
-			if chineseText, exists := prefixTranslations[turkishText]; exists {
-				return chineseText, true
-			}
-		}
-	}
+// TranslateWithSource returns the Chinese translation and the source that provided it
+// The source is empty and the original text is returned if no translation is found
+func TranslateWithSource(turkishText string, itemCode string) (string, string) {
+	return TranslateTo(DefaultLanguage, turkishText, itemCode)
+}
+
+// TranslateTo returns the translation into lang and the source that provided it
+// The source is empty and the original text is returned if no translation is found
+func TranslateTo(lang string, turkishText string, itemCode string) (string, string) {
+	match := MatchTranslation(lang, turkishText, itemCode)
+	return match.Text, match.Source
+}
+
+// MatchTranslation returns the translation into lang with the source that provided it
+// and whether the name only matched after normalization
+func MatchTranslation(lang string, turkishText string, itemCode string) TranslationMatch {
+	translationMutex.RLock()
+	defer translationMutex.RUnlock()
 
-	// Return original text if no translation found
-	return turkishText, false
+	return lookupTranslation(lang, turkishText, itemCode)
 }
 
 // ApplyTranslationsToBOM applies Chinese translations to BOM results
@@ -137,45 +775,61 @@ func ApplyTranslationsToBOM(results []BOMResult) []BOMResult {
 			translated := TranslateWithFallback(*result.SubItemName, result.BOMRecKaynakCode)
 			translatedResults[i].SubItemName = &translated
 		}
+
+		if result.ChildUnit != nil {
+			unit, _ := TranslateValue(DefaultLanguage, ValueFieldUnit, *result.ChildUnit)
+			translatedResults[i].ChildUnit = &unit
+		}
 	}
 
 	return translatedResults
 }
 
-// ApplyTranslationsToBOMWithTracking applies Chinese translations to BOM results and tracks failures
-// Returns translated results and a slice of item codes that failed to translate
-func ApplyTranslationsToBOMWithTracking(results []BOMResult) ([]BOMResult, []string) {
+// ApplyTranslationsToBOMWithTracking applies translations into lang to BOM results and tracks failures
+// Returns translated results and a report of the items that failed to translate or matched only after normalization
+// With verbose set, every result carries the provenance of its translated names
+func ApplyTranslationsToBOMWithTracking(results []BOMResult, lang string, verbose bool) ([]BOMResult, TranslationReport) {
+	translatedResults, report := applyTranslationsToBOM(results, lang, verbose, func(turkishText string, itemCode string) TranslationMatch {
+		return MatchTranslation(lang, turkishText, itemCode)
+	})
+
+	requestSuggestions(lang, report.reviewItems())
+	return translatedResults, report
+}
+
+// applyTranslationsToBOM translates the names of BOM results with match and tracks the results
+// Child units are translated with the value mapping of lang
+func applyTranslationsToBOM(results []BOMResult, lang string, verbose bool, match func(turkishText string, itemCode string) TranslationMatch) ([]BOMResult, TranslationReport) {
 	translatedResults := make([]BOMResult, len(results))
-	untranslatedCodesMap := make(map[string]bool)
-	var untranslatedCodes []string
+	var report TranslationReport
 
 	for i, result := range results {
 		translatedResults[i] = result
 
 		// Translate parent name with fallback using parent number
-		parentTranslated, parentSuccess := TranslateWithFallbackTracking(result.AD, result.BOMRecCode)
-		translatedResults[i].AD = parentTranslated
-
-		if !parentSuccess && result.BOMRecCode != "" {
-			if !untranslatedCodesMap[result.BOMRecCode] {
-				untranslatedCodesMap[result.BOMRecCode] = true
-				untranslatedCodes = append(untranslatedCodes, result.BOMRecCode)
-			}
-		}
+		parentMatch := match(result.AD, result.BOMRecCode)
+		translatedResults[i].AD = parentMatch.Text
+		report.track(result.BOMRecCode, result.AD, parentMatch)
+		provenance := FieldProvenance{ParentName: parentMatch.Provenance()}
 
 		// Translate child name if it exists, with fallback using child number
 		if result.SubItemName != nil && *result.SubItemName != "" {
-			childTranslated, childSuccess := TranslateWithFallbackTracking(*result.SubItemName, result.BOMRecKaynakCode)
-			translatedResults[i].SubItemName = &childTranslated
+			childMatch := match(*result.SubItemName, result.BOMRecKaynakCode)
+			translatedResults[i].SubItemName = &childMatch.Text
+			report.track(result.BOMRecKaynakCode, *result.SubItemName, childMatch)
+			provenance.ChildName = childMatch.Provenance()
+		}
 
-			if !childSuccess && result.BOMRecKaynakCode != "" {
-				if !untranslatedCodesMap[result.BOMRecKaynakCode] {
-					untranslatedCodesMap[result.BOMRecKaynakCode] = true
-					untranslatedCodes = append(untranslatedCodes, result.BOMRecKaynakCode)
-				}
-			}
+		// Translate the unit of the child, unmapped units stay Turkish
+		if result.ChildUnit != nil {
+			unit, _ := TranslateValue(lang, ValueFieldUnit, *result.ChildUnit)
+			translatedResults[i].ChildUnit = &unit
+		}
+
+		if verbose {
+			translatedResults[i].Provenance = &provenance
 		}
 	}
 
-	return translatedResults, untranslatedCodes
-}
\ No newline at end of file
+	return translatedResults, report
+}
@@ -0,0 +1,307 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Lint checks reported by LintTranslations
+const (
+	LintDuplicateToken = "duplicate-token"
+	LintSharedTarget   = "shared-target"
+	LintLatinTarget    = "latin-target"
+	LintWhitespace     = "whitespace"
+	LintCaseVariant    = "case-variant"
+	LintFallbackRepeat = "fallback-repeats-direct"
+	LintShadowedPrefix = "shadowed-prefix"
+)
+
+// languageScripts is the script translations of a language are expected to contain
+// Targets without a letter of that script were usually left untranslated
+var languageScripts = map[string]*unicode.RangeTable{
+	"cn": unicode.Han,
+	"ru": unicode.Cyrillic,
+}
+
+// LintIssue is a suspicious dictionary entry
+// Related lists the other sources (or prefixes) involved, e.g. the sources sharing a target
+type LintIssue struct {
+	Check   string   `json:"check"`
+	Scope   string   `json:"scope"`
+	Key     string   `json:"key,omitempty"`
+	Source  string   `json:"source,omitempty"`
+	Target  string   `json:"target,omitempty"`
+	Related []string `json:"related,omitempty"`
+	Message string   `json:"message"`
+}
+
+// LintReport is the result of linting the dictionaries of a language
+type LintReport struct {
+	Language string         `json:"language"`
+	Entries  int            `json:"entries"`
+	Issues   []LintIssue    `json:"issues"`
+	Counts   map[string]int `json:"counts"`
+}
+
+// lintEntry is a dictionary entry in the scope independent form the checks work on
+type lintEntry struct {
+	scope  string
+	key    string
+	source string
+	target string
+}
+
+// LintTranslations checks the stored dictionaries of a language for likely mistakes
+// The entries are not changed, every issue needs a human decision
+func LintTranslations(lang string) (LintReport, error) {
+	backend := activeTranslationBackend()
+
+	direct, _, err := backend.ReadDirect(lang)
+	if err != nil {
+		return LintReport{}, err
+	}
+	fallback, _, err := backend.ReadFallback(lang)
+	if err != nil {
+		return LintReport{}, err
+	}
+	codes, _, err := backend.ReadCode(lang)
+	if err != nil {
+		return LintReport{}, err
+	}
+
+	var entries []lintEntry
+	for source, target := range direct {
+		entries = append(entries, lintEntry{scope: ScopeDirect, source: source, target: target})
+	}
+	for prefix, prefixTranslations := range fallback {
+		for source, target := range prefixTranslations {
+			entries = append(entries, lintEntry{scope: ScopePrefix, key: prefix, source: source, target: target})
+		}
+	}
+	for itemCode, target := range codes {
+		entries = append(entries, lintEntry{scope: ScopeCode, key: itemCode, target: target})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].scope != entries[j].scope {
+			return entries[i].scope < entries[j].scope
+		}
+		if entries[i].key != entries[j].key {
+			return entries[i].key < entries[j].key
+		}
+		return entries[i].source < entries[j].source
+	})
+
+	var issues []LintIssue
+	issues = append(issues, lintEntries(entries, languageScripts[lang])...)
+	issues = append(issues, lintSharedTargets(entries)...)
+	issues = append(issues, lintCaseVariants(entries)...)
+	issues = append(issues, lintFallbackRepeats(direct, fallback)...)
+	for _, shadow := range FindShadowedPrefixes(fallback) {
+		issues = append(issues, LintIssue{
+			Check:   LintShadowedPrefix,
+			Scope:   ScopePrefix,
+			Key:     shadow.Prefix,
+			Source:  shadow.Name,
+			Target:  shadow.Target,
+			Related: []string{shadow.ShadowedBy},
+			Message: shadow.String(),
+		})
+	}
+
+	report := LintReport{Language: lang, Entries: len(entries), Issues: issues, Counts: make(map[string]int)}
+	if report.Issues == nil {
+		report.Issues = []LintIssue{}
+	}
+	for _, issue := range issues {
+		report.Counts[issue.Check]++
+	}
+	return report, nil
+}
+
+// lintEntries runs the checks that look at one entry at a time
+func lintEntries(entries []lintEntry, script *unicode.RangeTable) []LintIssue {
+	var issues []LintIssue
+	for _, entry := range entries {
+		issue := LintIssue{Scope: entry.scope, Key: entry.key, Source: entry.source, Target: entry.target}
+
+		for _, field := range []struct{ name, text string }{{"key", entry.key}, {"source", entry.source}, {"target", entry.target}} {
+			if field.text != strings.TrimSpace(field.text) {
+				issue.Check = LintWhitespace
+				issue.Message = fmt.Sprintf("%s %q has leading or trailing whitespace", field.name, field.text)
+				issues = append(issues, issue)
+			}
+		}
+
+		for _, field := range []struct{ name, text string }{{"source", entry.source}, {"target", entry.target}} {
+			if token, repeated := repeatedToken(field.text); repeated {
+				issue.Check = LintDuplicateToken
+				issue.Message = fmt.Sprintf("%s %q repeats %q", field.name, field.text, token)
+				issues = append(issues, issue)
+			}
+		}
+
+		if script != nil && !containsScript(entry.target, script) && containsScript(entry.target, unicode.Latin) {
+			issue.Check = LintLatinTarget
+			issue.Message = fmt.Sprintf("target %q has only Latin letters, it looks untranslated", entry.target)
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// lintSharedTargets reports targets used for different names in the same scope (and prefix)
+// Names that only differ in case, spacing or punctuation are the same name and are not reported
+func lintSharedTargets(entries []lintEntry) []LintIssue {
+	type group struct {
+		entries []lintEntry
+		names   map[string]bool
+	}
+	groups := make(map[string]*group)
+	var order []string
+	for _, entry := range entries {
+		if entry.scope == ScopeCode {
+			// Item code overrides share targets by design (one name for several codes)
+			continue
+		}
+		id := entry.scope + "\x00" + entry.key + "\x00" + strings.TrimSpace(entry.target)
+		if groups[id] == nil {
+			groups[id] = &group{names: make(map[string]bool)}
+			order = append(order, id)
+		}
+		groups[id].entries = append(groups[id].entries, entry)
+		groups[id].names[NormalizeName(entry.source, false)] = true
+	}
+
+	var issues []LintIssue
+	for _, id := range order {
+		g := groups[id]
+		if len(g.names) < 2 {
+			continue
+		}
+		sources := make([]string, len(g.entries))
+		for i, entry := range g.entries {
+			sources[i] = entry.source
+		}
+		for i, entry := range g.entries {
+			related := append(append([]string{}, sources[:i]...), sources[i+1:]...)
+			issues = append(issues, LintIssue{
+				Check:   LintSharedTarget,
+				Scope:   entry.scope,
+				Key:     entry.key,
+				Source:  entry.source,
+				Target:  entry.target,
+				Related: related,
+				Message: fmt.Sprintf("%q is also the translation of %s", entry.target, quoteAll(related)),
+			})
+		}
+	}
+	return issues
+}
+
+// lintCaseVariants reports names in the same scope (and prefix) that differ only by case
+func lintCaseVariants(entries []lintEntry) []LintIssue {
+	groups := make(map[string][]lintEntry)
+	var order []string
+	for _, entry := range entries {
+		if entry.scope == ScopeCode {
+			continue
+		}
+		id := entry.scope + "\x00" + entry.key + "\x00" + strings.ToLowerSpecial(unicode.TurkishCase, entry.source)
+		if groups[id] == nil {
+			order = append(order, id)
+		}
+		groups[id] = append(groups[id], entry)
+	}
+
+	var issues []LintIssue
+	for _, id := range order {
+		group := groups[id]
+		if len(group) < 2 {
+			continue
+		}
+		for i, entry := range group {
+			var related []string
+			for j, other := range group {
+				if i != j {
+					related = append(related, other.source)
+				}
+			}
+			message := fmt.Sprintf("%q differs only by case from %s", entry.source, quoteAll(related))
+			issues = append(issues, LintIssue{
+				Check:   LintCaseVariant,
+				Scope:   entry.scope,
+				Key:     entry.key,
+				Source:  entry.source,
+				Target:  entry.target,
+				Related: related,
+				Message: message,
+			})
+		}
+	}
+	return issues
+}
+
+// lintFallbackRepeats reports fallback entries with the same translation as the direct entry for the name
+// With the default order the direct entry always wins, so the fallback entry is never used
+func lintFallbackRepeats(direct map[string]string, fallback map[string]map[string]string) []LintIssue {
+	prefixes := make([]string, 0, len(fallback))
+	for prefix := range fallback {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+
+	var issues []LintIssue
+	for _, prefix := range prefixes {
+		sources := make([]string, 0, len(fallback[prefix]))
+		for source := range fallback[prefix] {
+			sources = append(sources, source)
+		}
+		sort.Strings(sources)
+
+		for _, source := range sources {
+			target := fallback[prefix][source]
+			if directTarget, exists := direct[source]; exists && directTarget == target {
+				issues = append(issues, LintIssue{
+					Check:   LintFallbackRepeat,
+					Scope:   ScopePrefix,
+					Key:     prefix,
+					Source:  source,
+					Target:  target,
+					Message: fmt.Sprintf("fallback %q under prefix %s repeats the direct translation", source, prefix),
+				})
+			}
+		}
+	}
+	return issues
+}
+
+// repeatedToken returns the first word that directly follows itself ("32x1 mm mm"), ignoring case
+func repeatedToken(text string) (string, bool) {
+	tokens := strings.Fields(text)
+	for i := 1; i < len(tokens); i++ {
+		if strings.EqualFold(tokens[i], tokens[i-1]) {
+			return tokens[i], true
+		}
+	}
+	return "", false
+}
+
+// containsScript reports whether text has a letter of the given script
+func containsScript(text string, script *unicode.RangeTable) bool {
+	for _, r := range text {
+		if unicode.Is(script, r) {
+			return true
+		}
+	}
+	return false
+}
+
+func quoteAll(texts []string) string {
+	quoted := make([]string, len(texts))
+	for i, text := range texts {
+		quoted[i] = fmt.Sprintf("%q", text)
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,250 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"resco/db"
+	"sort"
+	"strings"
+)
+
+// DefaultMissingPrefixLength is the item code prefix length used to group missing translations
+const DefaultMissingPrefixLength = 4
+
+// maxSampleCodes limits the item codes listed per missing name
+const maxSampleCodes = 5
+
+// columnPattern restricts the configured active item column to a plain column name
+var columnPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// activeItemFilter selects the active items of STOK00, an empty column scans all items
+var activeItemFilter struct {
+	column string
+	value  string
+}
+
+// MissingTranslationOptions controls the scope of a missing translations report
+type MissingTranslationOptions struct {
+	Language     string
+	Roots        []string
+	PrefixLength int
+}
+
+// MissingTranslation is a Turkish name without a translation and the items using it
+type MissingTranslation struct {
+	Name        string         `json:"name"`
+	Count       int            `json:"count"`
+	Prefixes    map[string]int `json:"prefixes"`
+	SampleCodes []string       `json:"sample-codes"`
+	// Composed is the unreviewed candidate composed from glossary terms, if any
+	Composed string `json:"composed,omitempty"`
+}
+
+// MissingPrefixGroup summarizes the untranslated items of one item code prefix
+type MissingPrefixGroup struct {
+	Prefix string `json:"prefix"`
+	Items  int    `json:"items"`
+	Names  int    `json:"names"`
+}
+
+// MissingTranslationReport lists the untranslated names of the catalog, most frequent first
+type MissingTranslationReport struct {
+	Language          string               `json:"language"`
+	Scope             string               `json:"scope"`
+	ScannedItems      int                  `json:"scanned-items"`
+	UntranslatedItems int                  `json:"untranslated-items"`
+	Names             []MissingTranslation `json:"names"`
+	Prefixes          []MissingPrefixGroup `json:"prefixes"`
+}
+
+// catalogItem is an item code with its Turkish name
+type catalogItem struct {
+	Code string
+	Name string
+}
+
+// SetActiveItemFilter configures which STOK00 items are active, e.g. column "AKTIF" with value "1"
+// An empty column disables the filter
+func SetActiveItemFilter(column, value string) error {
+	if column != "" && !columnPattern.MatchString(column) {
+		return fmt.Errorf("invalid column name: %q", column)
+	}
+	activeItemFilter.column = column
+	activeItemFilter.value = value
+	return nil
+}
+
+// GetMissingTranslations runs the names of the catalog through the translation chain and reports the untranslated ones
+// Without roots all active STOK00 items are scanned, otherwise the items reachable from the root items
+func GetMissingTranslations(ctx context.Context, conn *db.Connection, opts MissingTranslationOptions) (MissingTranslationReport, error) {
+	// Load translations if not already loaded
+	if err := LoadTranslations(); err != nil {
+		return MissingTranslationReport{}, fmt.Errorf("error loading translations: %v", err)
+	}
+
+	if opts.Language == "" {
+		opts.Language = DefaultLanguage
+	}
+	if err := checkLanguages([]string{opts.Language}); err != nil {
+		return MissingTranslationReport{}, err
+	}
+	if opts.PrefixLength <= 0 {
+		opts.PrefixLength = DefaultMissingPrefixLength
+	}
+
+	var items []catalogItem
+	var err error
+	scope := "catalog"
+	if len(opts.Roots) > 0 {
+		scope = "bom:" + strings.Join(opts.Roots, ",")
+		items, err = getBOMItems(ctx, conn, opts.Roots)
+	} else {
+		items, err = getCatalogItems(ctx, conn)
+	}
+	if err != nil {
+		return MissingTranslationReport{}, err
+	}
+
+	report := buildMissingReport(items, opts)
+	report.Scope = scope
+	return report, nil
+}
+
+// getCatalogItems returns the code and name of every active STOK00 item
+func getCatalogItems(ctx context.Context, conn *db.Connection) ([]catalogItem, error) {
+	query := fmt.Sprintf("SELECT TRIM(KOD), TRIM(AD) FROM %s WHERE KOD IS NOT NULL AND AD IS NOT NULL", conn.Table("STOK00"))
+	var args []interface{}
+	if activeItemFilter.column != "" {
+		query += fmt.Sprintf(" AND [%s] = @p1", activeItemFilter.column)
+		args = append(args, sql.Named("p1", activeItemFilter.value))
+	}
+
+	rows, err := conn.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query: %v", err)
+	}
+	defer rows.Close()
+
+	var items []catalogItem
+	for rows.Next() {
+		var item catalogItem
+		if err := rows.Scan(&item.Code, &item.Name); err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		items = append(items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+	return items, nil
+}
+
+// getBOMItems returns the distinct items (roots included) of the BOMs of the root items
+func getBOMItems(ctx context.Context, conn *db.Connection, roots []string) ([]catalogItem, error) {
+	seen := make(map[string]bool)
+	var items []catalogItem
+	add := func(code string, name *string) {
+		code = strings.TrimSpace(code)
+		if code == "" || name == nil || seen[code] {
+			return
+		}
+		seen[code] = true
+		items = append(items, catalogItem{Code: code, Name: *name})
+	}
+
+	for _, root := range roots {
+		results, err := GetBOMByCodeParameterized(ctx, conn, root)
+		if err != nil {
+			return nil, fmt.Errorf("error getting BOM of %s: %v", root, err)
+		}
+		for _, result := range results {
+			parentName := result.AD
+			add(result.BOMRecCode, &parentName)
+			add(result.BOMRecKaynakCode, result.SubItemName)
+		}
+	}
+	return items, nil
+}
+
+// buildMissingReport translates the item names and groups the failures by name and code prefix
+func buildMissingReport(items []catalogItem, opts MissingTranslationOptions) MissingTranslationReport {
+	report := MissingTranslationReport{
+		Language:     opts.Language,
+		ScannedItems: len(items),
+		Names:        []MissingTranslation{},
+		Prefixes:     []MissingPrefixGroup{},
+	}
+
+	byName := make(map[string]*MissingTranslation)
+	byPrefix := make(map[string]*MissingPrefixGroup)
+	prefixNames := make(map[string]map[string]bool)
+
+	for _, item := range items {
+		if strings.TrimSpace(item.Name) == "" {
+			continue
+		}
+		// Composed candidates are listed too, they have no reviewed translation yet
+		match := MatchTranslation(opts.Language, item.Name, item.Code)
+		if match.Source != "" && match.Source != SourceComposed {
+			continue
+		}
+		report.UntranslatedItems++
+
+		prefix := item.Code
+		if len(prefix) > opts.PrefixLength {
+			prefix = prefix[:opts.PrefixLength]
+		}
+
+		missing, exists := byName[item.Name]
+		if !exists {
+			missing = &MissingTranslation{Name: item.Name, Prefixes: make(map[string]int)}
+			if match.Source == SourceComposed {
+				missing.Composed = match.Text
+			}
+			byName[item.Name] = missing
+		}
+		missing.Count++
+		missing.Prefixes[prefix]++
+		if len(missing.SampleCodes) < maxSampleCodes {
+			missing.SampleCodes = append(missing.SampleCodes, item.Code)
+		}
+
+		group, exists := byPrefix[prefix]
+		if !exists {
+			group = &MissingPrefixGroup{Prefix: prefix}
+			byPrefix[prefix] = group
+			prefixNames[prefix] = make(map[string]bool)
+		}
+		group.Items++
+		if !prefixNames[prefix][item.Name] {
+			prefixNames[prefix][item.Name] = true
+			group.Names++
+		}
+	}
+
+	// Most frequent first, so translators start with the names that fix the most items
+	for _, missing := range byName {
+		report.Names = append(report.Names, *missing)
+	}
+	sort.Slice(report.Names, func(i, j int) bool {
+		if report.Names[i].Count != report.Names[j].Count {
+			return report.Names[i].Count > report.Names[j].Count
+		}
+		return report.Names[i].Name < report.Names[j].Name
+	})
+
+	for _, group := range byPrefix {
+		report.Prefixes = append(report.Prefixes, *group)
+	}
+	sort.Slice(report.Prefixes, func(i, j int) bool {
+		if report.Prefixes[i].Items != report.Prefixes[j].Items {
+			return report.Prefixes[i].Items > report.Prefixes[j].Items
+		}
+		return report.Prefixes[i].Prefix < report.Prefixes[j].Prefix
+	})
+
+	return report
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PrefixShadow describes a fallback entry hidden by an entry for the same name under a longer prefix
+// Redundant is set when both entries have the same translation, so the longer one can be removed
+type PrefixShadow struct {
+	Name         string `json:"name"`
+	Prefix       string `json:"prefix"`
+	Target       string `json:"target"`
+	ShadowedBy   string `json:"shadowed-by"`
+	ShadowTarget string `json:"shadow-target"`
+	Redundant    bool   `json:"redundant"`
+}
+
+// String returns the warning message of a shadowed entry
+func (s PrefixShadow) String() string {
+	if s.Redundant {
+		return fmt.Sprintf("fallback %q under prefix %s repeats the translation of prefix %s", s.Name, s.ShadowedBy, s.Prefix)
+	}
+	return fmt.Sprintf("fallback %q under prefix %s (%s) is overridden by prefix %s (%s)", s.Name, s.Prefix, s.Target, s.ShadowedBy, s.ShadowTarget)
+}
+
+// prefixLengths returns the distinct prefix lengths of a fallback dictionary, longest first
+func prefixLengths(fallbackTranslations map[string]map[string]string) []int {
+	seen := make(map[int]bool)
+	var lengths []int
+	for prefix := range fallbackTranslations {
+		if !seen[len(prefix)] {
+			seen[len(prefix)] = true
+			lengths = append(lengths, len(prefix))
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(lengths)))
+	return lengths
+}
+
+// lookupPrefixTranslation finds the fallback translation of the longest prefix of itemCode that has an entry for the name
+// The caller must hold translationMutex
+func (d *dictionary) lookupPrefixTranslation(turkishText string, itemCode string, normalize func() string) (TranslationMatch, bool) {
+	itemCode = strings.TrimSpace(itemCode)
+	for _, length := range d.fallbackPrefixLengths {
+		if len(itemCode) < length {
+			continue
+		}
+		prefix := itemCode[:length]
+		if translated, exists := d.fallbackTranslations[prefix][turkishText]; exists {
+			return TranslationMatch{Text: translated, Source: SourcePrefix, Prefix: prefix}, true
+		}
+		if d.normalized != nil {
+			if translated, exists := d.normalizedFallback[prefix][normalize()]; exists {
+				return TranslationMatch{Text: translated, Source: SourcePrefix, Prefix: prefix, Normalized: true}, true
+			}
+		}
+	}
+	return TranslationMatch{}, false
+}
+
+// FindShadowedPrefixes reports fallback entries hidden by an entry for the same name under a longer prefix
+// e.g. "Somun" under "80" is never used for item codes starting with "8010" when "8010" also has "Somun"
+func FindShadowedPrefixes(fallbackTranslations map[string]map[string]string) []PrefixShadow {
+	prefixes := make([]string, 0, len(fallbackTranslations))
+	for prefix := range fallbackTranslations {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+
+	var shadows []PrefixShadow
+	for _, prefix := range prefixes {
+		for _, longer := range prefixes {
+			if len(longer) <= len(prefix) || !strings.HasPrefix(longer, prefix) {
+				continue
+			}
+			for name, target := range fallbackTranslations[prefix] {
+				shadowTarget, exists := fallbackTranslations[longer][name]
+				if !exists {
+					continue
+				}
+				shadows = append(shadows, PrefixShadow{
+					Name:         name,
+					Prefix:       prefix,
+					Target:       target,
+					ShadowedBy:   longer,
+					ShadowTarget: shadowTarget,
+					Redundant:    target == shadowTarget,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(shadows, func(i, j int) bool {
+		if shadows[i].Prefix != shadows[j].Prefix {
+			return shadows[i].Prefix < shadows[j].Prefix
+		}
+		if shadows[i].Name != shadows[j].Name {
+			return shadows[i].Name < shadows[j].Name
+		}
+		return shadows[i].ShadowedBy < shadows[j].ShadowedBy
+	})
+	return shadows
+}
@@ -0,0 +1,78 @@
+package services
+
+import "testing"
+
+func TestLookupPrefixTranslation(t *testing.T) {
+	fallback := map[string]map[string]string{
+		"80":     {"Somun": "螺母", "Pul": "垫圈"},
+		"8010":   {"Somun": "活塞螺母"},
+		"801020": {"Amortisör Yağı": "减震器油"},
+		"36":     {"Somun": "防尘管螺母"},
+	}
+	opts := NormalizationOptions{Enabled: true, FoldDiacritics: true}
+	normalizedFallback := make(map[string]map[string]string)
+	for prefix, prefixTranslations := range fallback {
+		normalizedFallback[prefix], _ = buildNormalizedIndex(prefixTranslations, opts.FoldDiacritics)
+	}
+	d := &dictionary{
+		fallbackTranslations:  fallback,
+		fallbackPrefixLengths: prefixLengths(fallback),
+		normalized:            &opts,
+		normalizedFallback:    normalizedFallback,
+	}
+
+	tests := []struct {
+		name       string
+		itemCode   string
+		want       string
+		prefix     string
+		normalized bool
+	}{
+		// The longest prefix with an entry for the name wins
+		{"Somun", "80102099", "活塞螺母", "8010", false},
+		{"Somun", "80209999", "螺母", "80", false},
+		{"Pul", "80102099", "垫圈", "80", false},
+		{"Somun", " 36001234 ", "防尘管螺母", "36", false},
+		{"AMORTISOR YAGI", "80102001", "减震器油", "801020", true},
+		{"Somun", "70102099", "", "", false},
+		{"Somun", "8", "", "", false},
+		{"Somun", "", "", "", false},
+		{"Conta", "80102099", "", "", false},
+	}
+
+	for _, tt := range tests {
+		normalize := func() string { return NormalizeName(tt.name, opts.FoldDiacritics) }
+		match, ok := d.lookupPrefixTranslation(tt.name, tt.itemCode, normalize)
+		if tt.want == "" {
+			if ok {
+				t.Errorf("lookupPrefixTranslation(%q, %q) = %+v, want no match", tt.name, tt.itemCode, match)
+			}
+			continue
+		}
+		if !ok || match.Text != tt.want || match.Prefix != tt.prefix || match.Normalized != tt.normalized || match.Source != SourcePrefix {
+			t.Errorf("lookupPrefixTranslation(%q, %q) = %+v, want %q under %s (normalized %v)",
+				tt.name, tt.itemCode, match, tt.want, tt.prefix, tt.normalized)
+		}
+	}
+}
+
+func TestFindShadowedPrefixes(t *testing.T) {
+	shadows := FindShadowedPrefixes(map[string]map[string]string{
+		"80":   {"Somun": "螺母", "Pul": "垫圈"},
+		"8010": {"Somun": "活塞螺母", "Pul": "垫圈"},
+		"36":   {"Somun": "螺母"},
+	})
+
+	want := map[string]PrefixShadow{
+		"Somun": {Name: "Somun", Prefix: "80", Target: "螺母", ShadowedBy: "8010", ShadowTarget: "活塞螺母"},
+		"Pul":   {Name: "Pul", Prefix: "80", Target: "垫圈", ShadowedBy: "8010", ShadowTarget: "垫圈", Redundant: true},
+	}
+	if len(shadows) != len(want) {
+		t.Fatalf("FindShadowedPrefixes = %+v, want %d shadows", shadows, len(want))
+	}
+	for _, shadow := range shadows {
+		if shadow != want[shadow.Name] {
+			t.Errorf("shadow of %q = %+v, want %+v", shadow.Name, shadow, want[shadow.Name])
+		}
+	}
+}
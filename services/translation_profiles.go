@@ -0,0 +1,198 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrTranslationProfileNotFound is returned for unknown translation profiles
+	ErrTranslationProfileNotFound = errors.New("translation profile not found")
+	// ErrInvalidTranslationProfile is returned for malformed profile names
+	ErrInvalidTranslationProfile = errors.New("invalid translation profile")
+)
+
+// TranslationProfile describes the overrides of one customer
+// Entries counts the direct, fallback and item code entries per language
+type TranslationProfile struct {
+	Name      string         `json:"name"`
+	Languages []string       `json:"languages"`
+	Entries   map[string]int `json:"entries"`
+	APIKeys   int            `json:"api-keys"`
+}
+
+var (
+	// profiles holds the loaded profile dictionaries by profile and language, guarded by translationMutex
+	profiles map[string]map[string]*dictionary
+	// profileAPIKeys maps API keys to the profile of their customer
+	profileAPIKeys map[string]string
+)
+
+// translationProfilesDir holds one directory per customer profile, e.g. translate/profiles/acme/tr-to-cn.json
+func translationProfilesDir() string {
+	return filepath.Join(translationDir, "profiles")
+}
+
+// ValidateTranslationProfileName checks that a profile name is well formed, profiles are named like sets
+func ValidateTranslationProfileName(name string) error {
+	if !setNamePattern.MatchString(name) {
+		return fmt.Errorf("%w: %q (lower case letters, digits, '.', '_' and '-')", ErrInvalidTranslationProfile, name)
+	}
+	return nil
+}
+
+// SetTranslationProfileKeys configures the API keys selecting a profile, e.g. "k3y1=acme,k3y2=globex"
+func SetTranslationProfileKeys(config string) error {
+	keys := make(map[string]string)
+	for _, pair := range strings.Split(config, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, profile, ok := strings.Cut(pair, "=")
+		key, profile = strings.TrimSpace(key), strings.TrimSpace(profile)
+		if !ok || key == "" {
+			return fmt.Errorf("invalid API key entry: %q (expected key=profile)", pair)
+		}
+		if err := ValidateTranslationProfileName(profile); err != nil {
+			return err
+		}
+		if _, exists := keys[key]; exists {
+			return fmt.Errorf("duplicate API key for profile %s", profile)
+		}
+		keys[key] = profile
+	}
+
+	translationMutex.Lock()
+	profileAPIKeys = keys
+	translationMutex.Unlock()
+	return nil
+}
+
+// TranslationProfileForKey returns the profile of an API key, empty for unknown keys
+func TranslationProfileForKey(key string) string {
+	if key == "" {
+		return ""
+	}
+
+	translationMutex.RLock()
+	defer translationMutex.RUnlock()
+
+	return profileAPIKeys[key]
+}
+
+// readProfiles reads the dictionaries of every profile for the given base languages
+// Profile languages without a base dictionary are skipped with a warning
+func readProfiles(languages []string, opts NormalizationOptions) (map[string]map[string]*dictionary, []string, error) {
+	dirs, err := os.ReadDir(translationProfilesDir())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, fmt.Errorf("error reading %s: %v", translationProfilesDir(), err)
+	}
+
+	loaded := make(map[string]map[string]*dictionary)
+	var warnings []string
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		if err := ValidateTranslationProfileName(dir.Name()); err != nil {
+			warnings = append(warnings, "profile "+dir.Name()+": skipped, "+err.Error())
+			continue
+		}
+
+		reader := translationSetReader{dir: filepath.Join(translationProfilesDir(), dir.Name())}
+		loaded[dir.Name()] = make(map[string]*dictionary)
+		for _, lang := range reader.languages() {
+			if !containsString(languages, lang) {
+				warnings = append(warnings, fmt.Sprintf("profile %s: no base dictionary for %s, skipped", dir.Name(), lang))
+				continue
+			}
+			dict, err := readProfileDictionary(reader, lang, opts)
+			if err != nil {
+				return nil, nil, err
+			}
+			loaded[dir.Name()][lang] = dict
+		}
+	}
+	return loaded, warnings, nil
+}
+
+// readProfileDictionary reads the entries of a profile for one language
+// Profiles only override entries, the rules, glossary, size templates and value mappings of the base dictionary are shared
+// A profile entry for one size must not translate the other sizes of the name ahead of their base entries
+func readProfileDictionary(reader translationSetReader, lang string, opts NormalizationOptions) (*dictionary, error) {
+	dict, err := readDictionary(reader, lang, opts)
+	if err != nil {
+		return nil, err
+	}
+	dict.rules = nil
+	dict.glossary = nil
+	dict.reverse = nil
+	dict.sizeTemplates = nil
+	dict.valueEntries = nil
+	dict.values = nil
+	return dict, nil
+}
+
+// profileMatcher layers the entries of a profile over match: a profile entry wins over any base entry,
+// names the profile does not translate are looked up with match
+func profileMatcher(profile string, lang string, match func(turkishText string, itemCode string) TranslationMatch) (func(turkishText string, itemCode string) TranslationMatch, error) {
+	translationMutex.RLock()
+	_, exists := profiles[profile]
+	translationMutex.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrTranslationProfileNotFound, profile)
+	}
+
+	return func(turkishText string, itemCode string) TranslationMatch {
+		translationMutex.RLock()
+		dict, exists := profiles[profile][lang]
+		var profileMatch TranslationMatch
+		if exists {
+			profileMatch = dict.lookup(lang, turkishText, itemCode)
+		}
+		translationMutex.RUnlock()
+
+		if profileMatch.Source != "" {
+			profileMatch.Profile = profile
+			return profileMatch
+		}
+		return match(turkishText, itemCode)
+	}, nil
+}
+
+// ListTranslationProfiles returns the loaded profiles with their entry counts and the number of API keys selecting them
+func ListTranslationProfiles() ([]TranslationProfile, error) {
+	// Load translations if not already loaded
+	if err := LoadTranslations(); err != nil {
+		return nil, fmt.Errorf("error loading translations: %v", err)
+	}
+
+	translationMutex.RLock()
+	defer translationMutex.RUnlock()
+
+	list := make([]TranslationProfile, 0, len(profiles))
+	for name, dicts := range profiles {
+		profile := TranslationProfile{Name: name, Languages: []string{}, Entries: make(map[string]int)}
+		for lang, dict := range dicts {
+			profile.Languages = append(profile.Languages, lang)
+			stats := dict.stats()
+			profile.Entries[lang] = stats.DirectEntries + stats.FallbackEntries + stats.CodeOverrides
+		}
+		sort.Strings(profile.Languages)
+		for _, keyProfile := range profileAPIKeys {
+			if keyProfile == name {
+				profile.APIKeys++
+			}
+		}
+		list = append(list, profile)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list, nil
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProfileMatcherKeepsBaseSizes(t *testing.T) {
+	dir := t.TempDir()
+	profileEntries := `{"43x1.5 mm Gövde Borusu Hammadde": "43x1.5 mm 客户壳体管"}`
+	if err := os.WriteFile(filepath.Join(dir, "tr-to-cn.json"), []byte(profileEntries), 0644); err != nil {
+		t.Fatal(err)
+	}
+	profileDict, err := readProfileDictionary(translationSetReader{dir: dir}, "cn", NormalizationOptions{Enabled: true, FoldDiacritics: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	baseEntries := map[string]string{
+		"43x1.5 mm Gövde Borusu Hammadde": "43x1.5 mm 壳体管原材料",
+		"45x1.5 mm Gövde Borusu Hammadde": "45x1.5 mm 壳体管原材料",
+	}
+	base := &dictionary{translations: baseEntries, sizeTemplates: buildSizeTemplates(baseEntries, true), sizeTemplateFold: true}
+
+	previous := profiles
+	profiles = map[string]map[string]*dictionary{"acme": {"cn": profileDict}}
+	t.Cleanup(func() { profiles = previous })
+
+	match, err := profileMatcher("acme", "cn", func(turkishText string, itemCode string) TranslationMatch {
+		return base.lookup("cn", turkishText, itemCode)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		want    string
+		source  string
+		profile string
+	}{
+		{"43x1.5 mm Gövde Borusu Hammadde", "43x1.5 mm 客户壳体管", SourceDirect, "acme"},
+		{"45x1.5 mm Gövde Borusu Hammadde", "45x1.5 mm 壳体管原材料", SourceDirect, ""},
+		{"47x2 mm Gövde Borusu Hammadde", "47x2 mm 壳体管原材料", SourceTemplate, ""},
+	}
+
+	for _, tt := range tests {
+		got := match(tt.name, "")
+		if got.Text != tt.want || got.Source != tt.source || got.Profile != tt.profile {
+			t.Errorf("match(%q) = %q from %s (profile %q), want %q from %s (profile %q)",
+				tt.name, got.Text, got.Source, got.Profile, tt.want, tt.source, tt.profile)
+		}
+	}
+}
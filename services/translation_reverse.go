@@ -0,0 +1,275 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"resco/db"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Reverse lookup match kinds, best first
+const (
+	ReverseExact    = "exact"
+	ReversePartial  = "partial"
+	ReverseContains = "contained"
+)
+
+// DefaultReverseLimit is the number of reverse matches returned unless requested otherwise
+const DefaultReverseLimit = 20
+
+// reverseEntry is a dictionary entry indexed by its translation
+type reverseEntry struct {
+	target     string
+	normalized string
+	source     string
+	scope      string
+	key        string
+}
+
+// ReverseMatch is a dictionary entry whose translation matches a reverse lookup
+// ItemCodes lists up to maxReverseItemCodes of the active items using the entry, ItemCount all of them
+type ReverseMatch struct {
+	Target    string   `json:"target"`
+	Source    string   `json:"source,omitempty"`
+	Scope     string   `json:"scope"`
+	Key       string   `json:"key,omitempty"`
+	Match     string   `json:"match"`
+	ItemCodes []string `json:"item-codes"`
+	ItemCount int      `json:"item-count"`
+}
+
+// ReverseLookupResult is the result of a reverse lookup
+type ReverseLookupResult struct {
+	Language string         `json:"language"`
+	Query    string         `json:"query"`
+	Matches  []ReverseMatch `json:"matches"`
+}
+
+const maxReverseItemCodes = 20
+
+// buildReverseIndex indexes the direct, prefix fallback and item code entries of a dictionary by translation
+func buildReverseIndex(translations map[string]string, fallbackTranslations map[string]map[string]string, codeTranslations map[string]string) []reverseEntry {
+	var index []reverseEntry
+	for source, target := range translations {
+		index = append(index, reverseEntry{target: target, normalized: normalizeTarget(target), source: source, scope: ScopeDirect})
+	}
+	for prefix, prefixTranslations := range fallbackTranslations {
+		for source, target := range prefixTranslations {
+			index = append(index, reverseEntry{target: target, normalized: normalizeTarget(target), source: source, scope: ScopePrefix, key: prefix})
+		}
+	}
+	for itemCode, target := range codeTranslations {
+		index = append(index, reverseEntry{target: target, normalized: normalizeTarget(target), scope: ScopeCode, key: itemCode})
+	}
+
+	sort.Slice(index, func(i, j int) bool {
+		if index[i].normalized != index[j].normalized {
+			return index[i].normalized < index[j].normalized
+		}
+		if index[i].scope != index[j].scope {
+			return index[i].scope < index[j].scope
+		}
+		if index[i].key != index[j].key {
+			return index[i].key < index[j].key
+		}
+		return index[i].source < index[j].source
+	})
+	return index
+}
+
+// normalizeTarget folds a translation for reverse matching: full-width forms to ASCII, lower case, no whitespace
+// "SKD 减震器，挂车" and "skd减震器,挂车" are the same
+func normalizeTarget(text string) string {
+	var normalized strings.Builder
+	for _, r := range text {
+		switch {
+		case r >= 0xFF01 && r <= 0xFF5E:
+			r -= 0xFEE0
+		case r == '、':
+			r = ','
+		}
+		if unicode.IsSpace(r) {
+			continue
+		}
+		normalized.WriteRune(unicode.ToLower(r))
+	}
+	return normalized.String()
+}
+
+// reverseLookup returns the entries whose translation equals, contains or is contained in query, best matches first
+// Contained matches ("减震器" in "减震器主体总成") need at least two characters, single characters match too much
+func reverseLookup(lang string, query string, limit int) []ReverseMatch {
+	normalized := normalizeTarget(query)
+	if normalized == "" {
+		return nil
+	}
+
+	translationMutex.RLock()
+	defer translationMutex.RUnlock()
+
+	dict, exists := dictionaries[lang]
+	if !exists {
+		return nil
+	}
+
+	ranks := map[string]int{ReverseExact: 0, ReversePartial: 1, ReverseContains: 2}
+	var matches []ReverseMatch
+	for _, entry := range dict.reverse {
+		kind := ""
+		switch {
+		case entry.normalized == normalized:
+			kind = ReverseExact
+		case strings.Contains(entry.normalized, normalized):
+			kind = ReversePartial
+		case len([]rune(entry.normalized)) >= 2 && strings.Contains(normalized, entry.normalized):
+			kind = ReverseContains
+		default:
+			continue
+		}
+		matches = append(matches, ReverseMatch{Target: entry.target, Source: entry.source, Scope: entry.scope, Key: entry.key, Match: kind})
+	}
+
+	// Longer contained translations are more specific, shorter partial ones are closer to the query
+	sort.SliceStable(matches, func(i, j int) bool {
+		if ranks[matches[i].Match] != ranks[matches[j].Match] {
+			return ranks[matches[i].Match] < ranks[matches[j].Match]
+		}
+		li, lj := len([]rune(matches[i].Target)), len([]rune(matches[j].Target))
+		if matches[i].Match == ReverseContains {
+			return li > lj
+		}
+		return li < lj
+	})
+
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// ReverseTranslate finds the Turkish names and item codes behind a translation, e.g. a Chinese part name reported by the factory
+func ReverseTranslate(ctx context.Context, conn *db.Connection, lang string, query string, limit int) (ReverseLookupResult, error) {
+	// Load translations if not already loaded
+	if err := LoadTranslations(); err != nil {
+		return ReverseLookupResult{}, fmt.Errorf("error loading translations: %v", err)
+	}
+	if err := checkLanguages([]string{lang}); err != nil {
+		return ReverseLookupResult{}, err
+	}
+	if limit <= 0 {
+		limit = DefaultReverseLimit
+	}
+
+	result := ReverseLookupResult{Language: lang, Query: query, Matches: reverseLookup(lang, query, limit)}
+	if result.Matches == nil {
+		result.Matches = []ReverseMatch{}
+		return result, nil
+	}
+
+	items, err := getItemsForMatches(ctx, conn, result.Matches)
+	if err != nil {
+		return ReverseLookupResult{}, err
+	}
+
+	foldDiacritics := currentNormalization().FoldDiacritics
+
+	for i := range result.Matches {
+		match := &result.Matches[i]
+		match.ItemCodes = []string{}
+		for _, item := range items {
+			if !match.usedBy(item, foldDiacritics) {
+				continue
+			}
+			match.ItemCount++
+			if len(match.ItemCodes) < maxReverseItemCodes {
+				match.ItemCodes = append(match.ItemCodes, item.Code)
+			}
+		}
+	}
+	return result, nil
+}
+
+// usedBy reports whether the entry of a match translates the name of an item
+func (m ReverseMatch) usedBy(item catalogItem, foldDiacritics bool) bool {
+	switch m.Scope {
+	case ScopeCode:
+		return item.Code == m.Key
+	case ScopePrefix:
+		return strings.HasPrefix(item.Code, m.Key) && sameName(item.Name, m.Source, foldDiacritics)
+	}
+	return sameName(item.Name, m.Source, foldDiacritics)
+}
+
+// sameName compares names like the tolerant lookups do: case (Turkish rules, dotless ı), spacing and,
+// when configured, diacritics are ignored
+func sameName(a, b string, foldDiacritics bool) bool {
+	return NormalizeName(a, foldDiacritics) == NormalizeName(b, foldDiacritics)
+}
+
+// getItemsForMatches returns the active items with the item codes of the matches or named like their sources
+// Candidates are selected in the database by code and by name, with a case and accent insensitive collation so
+// "GÖVDE BORUSU" is found for "Gövde Borusu"; usedBy then compares the names like the tolerant lookups do
+func getItemsForMatches(ctx context.Context, conn *db.Connection, matches []ReverseMatch) ([]catalogItem, error) {
+	var names, codes []string
+	seen := make(map[string]bool)
+	for _, match := range matches {
+		if match.Scope == ScopeCode {
+			if !seen["code:"+match.Key] {
+				seen["code:"+match.Key] = true
+				codes = append(codes, match.Key)
+			}
+		} else if !seen["name:"+match.Source] {
+			seen["name:"+match.Source] = true
+			names = append(names, match.Source)
+		}
+	}
+
+	var conditions []string
+	var args []interface{}
+	placeholders := func(values []string, name string) string {
+		list := make([]string, len(values))
+		for i, value := range values {
+			param := fmt.Sprintf("%s%d", name, i)
+			list[i] = "@" + param
+			args = append(args, sql.Named(param, value))
+		}
+		return strings.Join(list, ", ")
+	}
+	if len(names) > 0 {
+		conditions = append(conditions, fmt.Sprintf("TRIM(AD) COLLATE Latin1_General_100_CI_AI IN (%s)", placeholders(names, "n")))
+	}
+	if len(codes) > 0 {
+		conditions = append(conditions, fmt.Sprintf("TRIM(KOD) IN (%s)", placeholders(codes, "c")))
+	}
+
+	query := fmt.Sprintf("SELECT TRIM(KOD), TRIM(AD) FROM %s WHERE KOD IS NOT NULL AND AD IS NOT NULL AND (%s)",
+		conn.Table("STOK00"), strings.Join(conditions, " OR "))
+	if activeItemFilter.column != "" {
+		query += fmt.Sprintf(" AND [%s] = @active", activeItemFilter.column)
+		args = append(args, sql.Named("active", activeItemFilter.value))
+	}
+	query += " ORDER BY KOD"
+
+	rows, err := conn.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error executing query: %v", err)
+	}
+	defer rows.Close()
+
+	var items []catalogItem
+	for rows.Next() {
+		var item catalogItem
+		if err := rows.Scan(&item.Code, &item.Name); err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		items = append(items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+	return items, nil
+}
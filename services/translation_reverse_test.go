@@ -0,0 +1,25 @@
+package services
+
+import "testing"
+
+func TestReverseMatchUsedBy(t *testing.T) {
+	tests := []struct {
+		match ReverseMatch
+		item  catalogItem
+		want  bool
+	}{
+		{ReverseMatch{Scope: ScopeDirect, Source: "Gövde Borusu"}, catalogItem{Code: "360001", Name: "GÖVDE BORUSU"}, true},
+		{ReverseMatch{Scope: ScopeDirect, Source: "Gövde Borusu"}, catalogItem{Code: "360001", Name: "Govde  Borusu"}, true},
+		{ReverseMatch{Scope: ScopeDirect, Source: "Gövde Borusu"}, catalogItem{Code: "360001", Name: "Gövde Borusu Kapağı"}, false},
+		{ReverseMatch{Scope: ScopePrefix, Key: "8010", Source: "Somun"}, catalogItem{Code: "80102099", Name: "SOMUN"}, true},
+		{ReverseMatch{Scope: ScopePrefix, Key: "8010", Source: "Somun"}, catalogItem{Code: "36001234", Name: "Somun"}, false},
+		{ReverseMatch{Scope: ScopeCode, Key: "360004"}, catalogItem{Code: "360004", Name: "Herhangi"}, true},
+		{ReverseMatch{Scope: ScopeCode, Key: "360004"}, catalogItem{Code: "360005", Name: "Herhangi"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.match.usedBy(tt.item, true); got != tt.want {
+			t.Errorf("%+v usedBy %+v = %v, want %v", tt.match, tt.item, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SourceRule is the translation source of pattern-based rules
+const SourceRule = "rule"
+
+// TranslationRule translates a family of names with a regular expression and a template
+// Capture groups (e.g. a dimension) are copied into the template, the rest of the template is the translation
+type TranslationRule struct {
+	ID          string `json:"id"`
+	Pattern     string `json:"pattern"`
+	Template    string `json:"template"`
+	Prefix      string `json:"prefix,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	regexp *regexp.Regexp
+}
+
+// templateReferencePattern finds $name, ${name}, $1 and ${1} references in a rule template, and $$ escapes
+// Like regexp.Expand, a bare name takes every following letter (Unicode included), digit and underscore,
+// so "$dim气缸管" references a group named "dim气缸管"; write "${dim}气缸管" instead
+var templateReferencePattern = regexp.MustCompile(`\$(\$|\{[^}]*\}|[\p{L}\p{Nd}_]+)`)
+
+// rulesTranslationsFile returns the rule file of a language, e.g. translate/rules-tr-to-cn.json
+func rulesTranslationsFile(lang string) string {
+	return filepath.Join(translationDir, "rules-tr-to-"+lang+".json")
+}
+
+// readRulesFile reads and validates a rule file
+// Rule ids must be unique, patterns must compile and templates may only reference existing groups
+func readRulesFile(path string) ([]TranslationRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", path, err)
+	}
+
+	var rules []TranslationRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+
+	seen := make(map[string]bool)
+	for i := range rules {
+		rule := &rules[i]
+		if rule.ID == "" || rule.Pattern == "" || rule.Template == "" {
+			return nil, fmt.Errorf("invalid rule #%d in %s: id, pattern and template are required", i+1, path)
+		}
+		if seen[rule.ID] {
+			return nil, fmt.Errorf("invalid rule in %s: duplicate id %s", path, rule.ID)
+		}
+		seen[rule.ID] = true
+
+		if err := rule.compile(); err != nil {
+			return nil, fmt.Errorf("invalid rule %s in %s: %v", rule.ID, path, err)
+		}
+	}
+
+	return rules, nil
+}
+
+// compile compiles the pattern and checks the group references of the template
+// Patterns match case-insensitively, with the Turkish İ and ı folded to i like in the folded text
+func (rule *TranslationRule) compile() error {
+	re, err := regexp.Compile("(?i)" + turkishIFolder.Replace(rule.Pattern))
+	if err != nil {
+		return fmt.Errorf("invalid pattern: %v", err)
+	}
+
+	for _, reference := range templateReferencePattern.FindAllStringSubmatch(rule.Template, -1) {
+		if reference[1] == "$" {
+			continue
+		}
+		name := strings.Trim(reference[1], "{}")
+		if n, err := strconv.Atoi(name); err == nil {
+			if n > re.NumSubexp() {
+				return fmt.Errorf("template references group %d, pattern has %d groups", n, re.NumSubexp())
+			}
+			continue
+		}
+		if re.SubexpIndex(name) < 0 {
+			return fmt.Errorf("template references unknown group %q", name)
+		}
+	}
+
+	rule.regexp = re
+	return nil
+}
+
+// apply returns the translation of text if the rule matches it
+// Rules with a prefix only apply to item codes starting with that prefix, an empty expansion is no match
+func (rule *TranslationRule) apply(text string, itemCode string) (string, bool) {
+	if rule.Prefix != "" && !strings.HasPrefix(strings.TrimSpace(itemCode), rule.Prefix) {
+		return "", false
+	}
+
+	folded, offsets := foldRuleText(text)
+	match := rule.regexp.FindStringSubmatchIndex(folded)
+	if match == nil {
+		return "", false
+	}
+	// Groups are copied from the original text, so "43X1.5 MM" keeps its spelling
+	for i, index := range match {
+		if index >= 0 {
+			match[i] = offsets[index]
+		}
+	}
+	translated := string(rule.regexp.ExpandString(nil, rule.Template, text, match))
+	if strings.TrimSpace(translated) == "" {
+		return "", false
+	}
+	return translated, true
+}
+
+// turkishIFolder folds the Turkish dotted and dotless i, which have no case-insensitive match in regexp
+var turkishIFolder = strings.NewReplacer("İ", "i", "ı", "i")
+
+// foldRuleText prepares a name for the rule patterns: whitespace runs become one space and İ and ı become i
+// offsets maps each byte of the folded text (and its end) to the byte offset in text
+func foldRuleText(text string) (string, []int) {
+	var b strings.Builder
+	offsets := make([]int, 0, len(text)+1)
+	space := false
+	for i, r := range text {
+		switch {
+		case unicode.IsSpace(r):
+			if space {
+				continue
+			}
+			space = true
+			r = ' '
+		case r == 'İ' || r == 'ı':
+			space = false
+			r = 'i'
+		default:
+			space = false
+		}
+		for n := utf8.RuneLen(r); n > 0; n-- {
+			offsets = append(offsets, i)
+		}
+		b.WriteRune(r)
+	}
+	return b.String(), append(offsets, len(text))
+}
+
+// matchRule returns the translation of the first matching rule and its id
+func matchRule(rules []TranslationRule, text string, itemCode string) (string, string, bool) {
+	text = strings.TrimSpace(text)
+	for i := range rules {
+		if translated, ok := rules[i].apply(text, itemCode); ok {
+			return translated, rules[i].ID, true
+		}
+	}
+	return "", "", false
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatchRule(t *testing.T) {
+	rules := []TranslationRule{
+		{ID: "cylinder-tube-raw", Pattern: `^(?P<dim>\d+(?:\.\d+)?x\d+(?:\.\d+)?)\s*mm Silindir Borusu Hammadde$`, Template: "${dim} mm 气缸管原材料"},
+		{ID: "numbered", Pattern: `^(\d+)x(\d+) Conta$`, Template: "${1}x$2 密封垫"},
+		{ID: "dollar", Pattern: `^Fiyat (\d+)$`, Template: "价格 $$${1}"},
+		{ID: "prefixed", Pattern: `^Somun$`, Template: "活塞螺母", Prefix: "8010"},
+		{ID: "empty", Pattern: `^Boş(?P<rest>.*)$`, Template: "${rest}"},
+	}
+	for i := range rules {
+		if err := rules[i].compile(); err != nil {
+			t.Fatalf("compile %s: %v", rules[i].ID, err)
+		}
+	}
+
+	tests := []struct {
+		text     string
+		itemCode string
+		want     string
+		rule     string
+	}{
+		{"33x1.5 mm Silindir Borusu Hammadde", "", "33x1.5 mm 气缸管原材料", "cylinder-tube-raw"},
+		{" 33x1.5mm Silindir Borusu Hammadde ", "", "33x1.5 mm 气缸管原材料", "cylinder-tube-raw"},
+		// ERP names in upper case, with the Turkish İ or repeated spaces
+		{"43X1.5 MM SİLİNDİR BORUSU HAMMADDE", "", "43X1.5 mm 气缸管原材料", "cylinder-tube-raw"},
+		{"43x1.5 mm  Silindir   Borusu Hammadde", "", "43x1.5 mm 气缸管原材料", "cylinder-tube-raw"},
+		{"43X1.5MM SILINDIR BORUSU HAMMADDE", "", "43X1.5 mm 气缸管原材料", "cylinder-tube-raw"},
+		{"12x4 Conta", "", "12x4 密封垫", "numbered"},
+		{"12X4 CONTA", "", "12x4 密封垫", "numbered"},
+		{"Boş  İç  kutu", "", "  İç  kutu", "empty"},
+		{"Fiyat 40", "", "价格 $40", "dollar"},
+		{"Somun", "80102099", "活塞螺母", "prefixed"},
+		{"Somun", "36001234", "", ""},
+		// An empty expansion is no match
+		{"Boş  ", "", "", ""},
+		{"Boş kutu", "", " kutu", "empty"},
+		{"Silindir Borusu", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		translated, ruleID, ok := matchRule(rules, tt.text, tt.itemCode)
+		if tt.want == "" {
+			if ok {
+				t.Errorf("matchRule(%q, %q) = %q by %s, want no match", tt.text, tt.itemCode, translated, ruleID)
+			}
+			continue
+		}
+		if !ok || translated != tt.want || ruleID != tt.rule {
+			t.Errorf("matchRule(%q, %q) = %q by %s, want %q by %s", tt.text, tt.itemCode, translated, ruleID, tt.want, tt.rule)
+		}
+	}
+}
+
+func TestReadRulesFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{"valid", `[{"id": "a", "pattern": "^(?P<dim>\\d+) mm$", "template": "${dim} 毫米 $$"}]`, ""},
+		{"missing template", `[{"id": "a", "pattern": "^x$"}]`, "id, pattern and template are required"},
+		{"duplicate id", `[{"id": "a", "pattern": "^x$", "template": "x"}, {"id": "a", "pattern": "^y$", "template": "y"}]`, "duplicate id a"},
+		{"invalid pattern", `[{"id": "a", "pattern": "(", "template": "x"}]`, "invalid pattern"},
+		{"unknown group", `[{"id": "a", "pattern": "^(?P<dim>\\d+)$", "template": "${size}"}]`, `unknown group "size"`},
+		{"name running into text", `[{"id": "a", "pattern": "^(?P<dim>\\d+)$", "template": "$dim气缸管"}]`, `unknown group "dim气缸管"`},
+		{"number running into text", `[{"id": "a", "pattern": "^(\\d+)x(\\d+)$", "template": "$1x$2"}]`, `unknown group "1x"`},
+		{"group number", `[{"id": "a", "pattern": "^(\\d+)$", "template": "$2"}]`, "references group 2, pattern has 1 groups"},
+		{"invalid JSON", `{`, "error parsing"},
+	}
+
+	dir := t.TempDir()
+	for _, tt := range tests {
+		path := filepath.Join(dir, "rules.json")
+		if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		rules, err := readRulesFile(path)
+		if tt.err == "" {
+			if err != nil || len(rules) != 1 {
+				t.Errorf("%s: readRulesFile = %d rules, %v", tt.name, len(rules), err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,682 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ActiveTranslationSet names the translations in use, stored by the translation backend
+const ActiveTranslationSet = "active"
+
+// Translation set states
+const (
+	SetStatusActive     = "active"
+	SetStatusDraft      = "draft"
+	SetStatusArchived   = "archived"
+	SetStatusRestored   = "restored"
+	SetStatusRolledBack = "rolled-back"
+)
+
+// Kinds of differences between two translation sets
+const (
+	SetChangeAdded   = "added"
+	SetChangeRemoved = "removed"
+	SetChangeChanged = "changed"
+)
+
+// translationSetInfoFile holds the status of a set next to its dictionary files
+const translationSetInfoFile = "set.json"
+
+var (
+	// ErrTranslationSetNotFound is returned for unknown sets and for sets without the requested language
+	ErrTranslationSetNotFound = errors.New("translation set not found")
+	// ErrTranslationSetExists is returned when a set is created with the name of an existing set
+	ErrTranslationSetExists = errors.New("translation set already exists")
+	// ErrInvalidTranslationSet is returned for malformed set names
+	ErrInvalidTranslationSet = errors.New("invalid translation set")
+	// ErrNothingToRollBack is returned when no archived set holds the language
+	ErrNothingToRollBack = errors.New("no archived translation set to roll back to")
+)
+
+var setNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+
+// TranslationSetContent holds the direct, prefix fallback and item code dictionaries of one language
+type TranslationSetContent struct {
+	Direct   map[string]string
+	Fallback map[string]map[string]string
+	Code     map[string]string
+}
+
+// TranslationSet describes the active translations or a named copy of the dictionaries
+// Entries counts the direct, fallback and item code entries per language
+type TranslationSet struct {
+	Name       string         `json:"name"`
+	Status     string         `json:"status"`
+	Languages  []string       `json:"languages"`
+	Entries    map[string]int `json:"entries"`
+	CreatedAt  *time.Time     `json:"created-at,omitempty"`
+	CreatedBy  string         `json:"created-by,omitempty"`
+	CopiedFrom string         `json:"copied-from,omitempty"`
+	Note       string         `json:"note,omitempty"`
+}
+
+// translationSetInfo is the content of set.json
+type translationSetInfo struct {
+	Status     string    `json:"status"`
+	CreatedAt  time.Time `json:"created-at"`
+	CreatedBy  string    `json:"created-by,omitempty"`
+	CopiedFrom string    `json:"copied-from,omitempty"`
+	Note       string    `json:"note,omitempty"`
+}
+
+// TranslationSetChange is an entry that differs between two sets
+type TranslationSetChange struct {
+	Change    string `json:"change"`
+	Scope     string `json:"scope"`
+	Key       string `json:"key,omitempty"`
+	Source    string `json:"source,omitempty"`
+	Target    string `json:"target,omitempty"`
+	OldTarget string `json:"old-target,omitempty"`
+}
+
+// TranslationSetDiff lists the changes that turn one set into another for a language
+type TranslationSetDiff struct {
+	Language string                 `json:"language"`
+	From     string                 `json:"from"`
+	To       string                 `json:"to"`
+	Changes  []TranslationSetChange `json:"changes"`
+	Counts   map[string]int         `json:"counts"`
+}
+
+// TranslationPromotion is the result of promoting a set or rolling back
+// Archived names the set holding the replaced active translations, empty when nothing changed
+type TranslationPromotion struct {
+	Language string         `json:"language"`
+	Promoted string         `json:"promoted"`
+	Archived string         `json:"archived,omitempty"`
+	Counts   map[string]int `json:"counts"`
+}
+
+// translationSetsDir holds one directory per set, e.g. translate/sets/draft/fallback-tr-to-cn.json
+func translationSetsDir() string {
+	return filepath.Join(translationDir, "sets")
+}
+
+// translationSetReader reads the dictionary files of a set, named like the files in translate/
+type translationSetReader struct {
+	dir string
+}
+
+func (s translationSetReader) ReadDirect(lang string) (map[string]string, string, error) {
+	return readFlatDictionaryFile(filepath.Join(s.dir, filepath.Base(directTranslationsFile(lang))))
+}
+
+func (s translationSetReader) ReadFallback(lang string) (map[string]map[string]string, string, error) {
+	return readFallbackDictionaryFile(filepath.Join(s.dir, filepath.Base(fallbackTranslationsFile(lang))))
+}
+
+func (s translationSetReader) ReadCode(lang string) (map[string]string, string, error) {
+	return readFlatDictionaryFile(filepath.Join(s.dir, filepath.Base(codeTranslationsFile(lang))))
+}
+
+// versions returns the versions of the direct, fallback and item code files of a language, without parsing them
+func (s translationSetReader) versions(lang string) (string, error) {
+	var versions []string
+	for _, path := range []string{directTranslationsFile(lang), fallbackTranslationsFile(lang), codeTranslationsFile(lang)} {
+		_, version, err := readDictionaryFile(filepath.Join(s.dir, filepath.Base(path)))
+		if err != nil {
+			return "", err
+		}
+		versions = append(versions, version)
+	}
+	return strings.Join(versions, ","), nil
+}
+
+// languages returns the languages with at least one dictionary file in the set
+func (s translationSetReader) languages() []string {
+	paths, _ := filepath.Glob(filepath.Join(s.dir, "*tr-to-*.json"))
+	seen := make(map[string]bool)
+	var languages []string
+	for _, path := range paths {
+		name := filepath.Base(path)
+		lang := strings.TrimSuffix(name[strings.Index(name, "tr-to-")+len("tr-to-"):], ".json")
+		if ValidateLanguage(lang) != nil || seen[lang] {
+			continue
+		}
+		seen[lang] = true
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// changes returns the entries of the content as changes of lang, ordered by scope, key and source
+func (c TranslationSetContent) changes(lang string) []TranslationChange {
+	var changes []TranslationChange
+	for source, target := range c.Direct {
+		changes = append(changes, TranslationChange{Language: lang, Scope: ScopeDirect, Source: source, Target: target})
+	}
+	for prefix, prefixTranslations := range c.Fallback {
+		for source, target := range prefixTranslations {
+			changes = append(changes, TranslationChange{Language: lang, Scope: ScopePrefix, Key: prefix, Source: source, Target: target})
+		}
+	}
+	for itemCode, target := range c.Code {
+		changes = append(changes, TranslationChange{Language: lang, Scope: ScopeCode, Key: itemCode, Target: target})
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Scope != changes[j].Scope {
+			return changes[i].Scope < changes[j].Scope
+		}
+		if changes[i].Key != changes[j].Key {
+			return changes[i].Key < changes[j].Key
+		}
+		return changes[i].Source < changes[j].Source
+	})
+	return changes
+}
+
+func (c TranslationSetContent) entries() int {
+	count := len(c.Direct) + len(c.Code)
+	for _, prefixTranslations := range c.Fallback {
+		count += len(prefixTranslations)
+	}
+	return count
+}
+
+// checkSetName validates a set name, "active" is accepted only when allowActive is set
+func checkSetName(name string, allowActive bool) error {
+	if name == ActiveTranslationSet {
+		if allowActive {
+			return nil
+		}
+		return fmt.Errorf("%w: %q is the active translations", ErrInvalidTranslationSet, name)
+	}
+	if !setNamePattern.MatchString(name) {
+		return fmt.Errorf("%w: %q (lower case letters, digits, '.', '_' and '-')", ErrInvalidTranslationSet, name)
+	}
+	return nil
+}
+
+// ValidateTranslationSetName checks that a set name is well formed, "active" included
+func ValidateTranslationSetName(name string) error {
+	return checkSetName(name, true)
+}
+
+// readSetInfo reads set.json of a set, a missing directory is ErrTranslationSetNotFound
+func readSetInfo(name string) (translationSetInfo, error) {
+	path := filepath.Join(translationSetsDir(), name, translationSetInfoFile)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		if _, statErr := os.Stat(filepath.Dir(path)); statErr != nil {
+			return translationSetInfo{}, fmt.Errorf("%w: %s", ErrTranslationSetNotFound, name)
+		}
+		// Directories created by hand are drafts
+		return translationSetInfo{Status: SetStatusDraft}, nil
+	}
+	if err != nil {
+		return translationSetInfo{}, fmt.Errorf("error reading %s: %v", path, err)
+	}
+
+	var info translationSetInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return translationSetInfo{}, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+	return info, nil
+}
+
+func writeSetInfo(name string, info translationSetInfo) error {
+	data, err := marshalDictionary(info)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(translationSetsDir(), name, translationSetInfoFile), data)
+}
+
+// readSetContent reads the dictionaries of a language from a set, or from the backend for the active set
+// versions holds the version of each scope of the active set, used to detect edits during a promotion
+func readSetContent(name string, lang string) (TranslationSetContent, map[string]string, error) {
+	var reader translationReader = activeTranslationBackend()
+	if name != ActiveTranslationSet {
+		setReader, err := setLanguageReader(name, lang)
+		if err != nil {
+			return TranslationSetContent{}, nil, err
+		}
+		reader = setReader
+	}
+
+	var content TranslationSetContent
+	versions := make(map[string]string)
+	var err error
+	if content.Direct, versions[ScopeDirect], err = reader.ReadDirect(lang); err != nil {
+		return TranslationSetContent{}, nil, err
+	}
+	if content.Fallback, versions[ScopePrefix], err = reader.ReadFallback(lang); err != nil {
+		return TranslationSetContent{}, nil, err
+	}
+	if content.Code, versions[ScopeCode], err = reader.ReadCode(lang); err != nil {
+		return TranslationSetContent{}, nil, err
+	}
+	return content, versions, nil
+}
+
+// setLanguageReader returns the reader of a named set, ErrTranslationSetNotFound when the set or its lang dictionaries do not exist
+func setLanguageReader(name string, lang string) (translationSetReader, error) {
+	if _, err := readSetInfo(name); err != nil {
+		return translationSetReader{}, err
+	}
+	reader := translationSetReader{dir: filepath.Join(translationSetsDir(), name)}
+	if !containsString(reader.languages(), lang) {
+		return translationSetReader{}, fmt.Errorf("%w: %s has no %s dictionaries", ErrTranslationSetNotFound, name, lang)
+	}
+	return reader, nil
+}
+
+// writeSet writes the dictionaries and set.json of a new set, the caller must hold translationWriteMutex
+func writeSet(name string, info translationSetInfo, contents map[string]TranslationSetContent) error {
+	dir := filepath.Join(translationSetsDir(), name)
+	if _, err := os.Stat(dir); err == nil {
+		return fmt.Errorf("%w: %s", ErrTranslationSetExists, name)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("error creating translation set: %v", err)
+	}
+
+	for lang, content := range contents {
+		for _, file := range []struct {
+			path    string
+			entries interface{}
+		}{
+			{directTranslationsFile(lang), content.Direct},
+			{fallbackTranslationsFile(lang), content.Fallback},
+			{codeTranslationsFile(lang), content.Code},
+		} {
+			data, err := marshalDictionary(file.entries)
+			if err == nil {
+				err = writeFileAtomic(filepath.Join(dir, filepath.Base(file.path)), data)
+			}
+			if err != nil {
+				os.RemoveAll(dir)
+				return err
+			}
+		}
+	}
+
+	if err := writeSetInfo(name, info); err != nil {
+		os.RemoveAll(dir)
+		return err
+	}
+	return nil
+}
+
+// ListTranslationSets returns the active translations followed by the stored sets, newest first
+func ListTranslationSets() ([]TranslationSet, error) {
+	languages, err := activeTranslationBackend().Languages()
+	if err != nil {
+		return nil, err
+	}
+	active := TranslationSet{Name: ActiveTranslationSet, Status: SetStatusActive, Languages: languages, Entries: make(map[string]int)}
+	for _, lang := range languages {
+		content, _, err := readSetContent(ActiveTranslationSet, lang)
+		if err != nil {
+			return nil, err
+		}
+		active.Entries[lang] = content.entries()
+	}
+
+	dirs, err := os.ReadDir(translationSetsDir())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("error reading %s: %v", translationSetsDir(), err)
+	}
+
+	var sets []TranslationSet
+	for _, dir := range dirs {
+		if !dir.IsDir() || checkSetName(dir.Name(), false) != nil {
+			continue
+		}
+		set, err := describeSet(dir.Name())
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, set)
+	}
+	sort.SliceStable(sets, func(i, j int) bool {
+		if sets[i].CreatedAt == nil || sets[j].CreatedAt == nil {
+			return sets[i].CreatedAt != nil
+		}
+		return sets[i].CreatedAt.After(*sets[j].CreatedAt)
+	})
+
+	return append([]TranslationSet{active}, sets...), nil
+}
+
+// describeSet reads the status and entry counts of a stored set
+func describeSet(name string) (TranslationSet, error) {
+	info, err := readSetInfo(name)
+	if err != nil {
+		return TranslationSet{}, err
+	}
+
+	set := TranslationSet{
+		Name:       name,
+		Status:     info.Status,
+		Entries:    make(map[string]int),
+		CreatedBy:  info.CreatedBy,
+		CopiedFrom: info.CopiedFrom,
+		Note:       info.Note,
+	}
+	if !info.CreatedAt.IsZero() {
+		createdAt := info.CreatedAt
+		set.CreatedAt = &createdAt
+	}
+
+	set.Languages = translationSetReader{dir: filepath.Join(translationSetsDir(), name)}.languages()
+	for _, lang := range set.Languages {
+		content, _, err := readSetContent(name, lang)
+		if err != nil {
+			return TranslationSet{}, err
+		}
+		set.Entries[lang] = content.entries()
+	}
+	return set, nil
+}
+
+// CreateTranslationSet stores a draft copy of every language of another set, the active translations by default
+func CreateTranslationSet(name string, from string, note string, author string) (TranslationSet, error) {
+	if err := checkSetName(name, false); err != nil {
+		return TranslationSet{}, err
+	}
+	if from == "" {
+		from = ActiveTranslationSet
+	}
+	if err := checkSetName(from, true); err != nil {
+		return TranslationSet{}, err
+	}
+
+	translationWriteMutex.Lock()
+	defer translationWriteMutex.Unlock()
+
+	var languages []string
+	if from == ActiveTranslationSet {
+		var err error
+		if languages, err = activeTranslationBackend().Languages(); err != nil {
+			return TranslationSet{}, err
+		}
+	} else {
+		if _, err := readSetInfo(from); err != nil {
+			return TranslationSet{}, err
+		}
+		languages = translationSetReader{dir: filepath.Join(translationSetsDir(), from)}.languages()
+	}
+
+	contents := make(map[string]TranslationSetContent)
+	for _, lang := range languages {
+		content, _, err := readSetContent(from, lang)
+		if err != nil {
+			return TranslationSet{}, err
+		}
+		contents[lang] = content
+	}
+
+	info := translationSetInfo{Status: SetStatusDraft, CreatedAt: time.Now().UTC(), CreatedBy: author, CopiedFrom: from, Note: note}
+	if err := writeSet(name, info, contents); err != nil {
+		return TranslationSet{}, err
+	}
+	return describeSet(name)
+}
+
+// DiffTranslationSets returns the changes that turn the dictionaries of from into those of to
+func DiffTranslationSets(lang string, from string, to string) (TranslationSetDiff, error) {
+	for _, name := range []string{from, to} {
+		if err := checkSetName(name, true); err != nil {
+			return TranslationSetDiff{}, err
+		}
+	}
+
+	fromContent, _, err := readSetContent(from, lang)
+	if err != nil {
+		return TranslationSetDiff{}, err
+	}
+	toContent, _, err := readSetContent(to, lang)
+	if err != nil {
+		return TranslationSetDiff{}, err
+	}
+
+	diff := TranslationSetDiff{Language: lang, From: from, To: to, Changes: diffSetContent(lang, fromContent, toContent)}
+	diff.Counts = countSetChanges(diff.Changes)
+	return diff, nil
+}
+
+// diffSetContent compares two sets entry by entry, ordered by scope, key and source
+func diffSetContent(lang string, from, to TranslationSetContent) []TranslationSetChange {
+	id := func(change TranslationChange) string {
+		return change.Scope + "\x00" + change.Key + "\x00" + change.Source
+	}
+
+	oldTargets := make(map[string]string)
+	for _, change := range from.changes(lang) {
+		oldTargets[id(change)] = change.Target
+	}
+
+	changes := []TranslationSetChange{}
+	seen := make(map[string]bool)
+	for _, change := range to.changes(lang) {
+		seen[id(change)] = true
+		entry := TranslationSetChange{Scope: change.Scope, Key: change.Key, Source: change.Source, Target: change.Target}
+		oldTarget, exists := oldTargets[id(change)]
+		switch {
+		case !exists:
+			entry.Change = SetChangeAdded
+		case oldTarget != change.Target:
+			entry.Change = SetChangeChanged
+			entry.OldTarget = oldTarget
+		default:
+			continue
+		}
+		changes = append(changes, entry)
+	}
+	for _, change := range from.changes(lang) {
+		if !seen[id(change)] {
+			changes = append(changes, TranslationSetChange{Change: SetChangeRemoved, Scope: change.Scope, Key: change.Key, Source: change.Source, OldTarget: change.Target})
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Scope != changes[j].Scope {
+			return changes[i].Scope < changes[j].Scope
+		}
+		if changes[i].Key != changes[j].Key {
+			return changes[i].Key < changes[j].Key
+		}
+		return changes[i].Source < changes[j].Source
+	})
+	return changes
+}
+
+func countSetChanges(changes []TranslationSetChange) map[string]int {
+	counts := map[string]int{SetChangeAdded: 0, SetChangeRemoved: 0, SetChangeChanged: 0}
+	for _, change := range changes {
+		counts[change.Change]++
+	}
+	return counts
+}
+
+// PromoteTranslationSet makes the dictionaries of a set the active translations of a language
+// The replaced active translations are archived first, so the promotion can be rolled back
+func PromoteTranslationSet(name string, lang string, author string) (TranslationPromotion, error) {
+	if err := checkSetName(name, false); err != nil {
+		return TranslationPromotion{}, err
+	}
+
+	translationWriteMutex.Lock()
+	defer translationWriteMutex.Unlock()
+
+	return promoteTranslationSet(name, lang, author, SetStatusArchived)
+}
+
+// RollbackTranslationSet restores the newest archived translations of a language
+// The restored archive is marked "restored" and the replaced translations "rolled-back", so repeated rollbacks go further back
+func RollbackTranslationSet(lang string, author string) (TranslationPromotion, error) {
+	translationWriteMutex.Lock()
+	defer translationWriteMutex.Unlock()
+
+	sets, err := ListTranslationSets()
+	if err != nil {
+		return TranslationPromotion{}, err
+	}
+
+	for _, set := range sets {
+		if set.Status != SetStatusArchived || !containsString(set.Languages, lang) {
+			continue
+		}
+
+		promotion, err := promoteTranslationSet(set.Name, lang, author, SetStatusRolledBack)
+		if err != nil {
+			return TranslationPromotion{}, err
+		}
+
+		info, err := readSetInfo(set.Name)
+		if err == nil {
+			info.Status = SetStatusRestored
+			err = writeSetInfo(set.Name, info)
+		}
+		if err != nil {
+			return TranslationPromotion{}, fmt.Errorf("translations rolled back but %s could not be marked restored: %v", set.Name, err)
+		}
+		return promotion, nil
+	}
+	return TranslationPromotion{}, fmt.Errorf("%w: %s", ErrNothingToRollBack, lang)
+}
+
+// promoteTranslationSet archives the active translations of a language with archiveStatus and replaces them with a set
+// The caller must hold translationWriteMutex
+func promoteTranslationSet(name string, lang string, author string, archiveStatus string) (TranslationPromotion, error) {
+	if err := checkLanguages([]string{lang}); err != nil {
+		return TranslationPromotion{}, err
+	}
+	content, _, err := readSetContent(name, lang)
+	if err != nil {
+		return TranslationPromotion{}, err
+	}
+	active, versions, err := readSetContent(ActiveTranslationSet, lang)
+	if err != nil {
+		return TranslationPromotion{}, err
+	}
+
+	promotion := TranslationPromotion{Language: lang, Promoted: name, Counts: countSetChanges(diffSetContent(lang, active, content))}
+	if promotion.Counts[SetChangeAdded]+promotion.Counts[SetChangeRemoved]+promotion.Counts[SetChangeChanged] == 0 {
+		return promotion, nil
+	}
+
+	now := time.Now().UTC()
+	promotion.Archived = fmt.Sprintf("archive-%s-%s", lang, now.Format("20060102-150405.000"))
+	info := translationSetInfo{Status: archiveStatus, CreatedAt: now, CreatedBy: author, CopiedFrom: ActiveTranslationSet, Note: "replaced by " + name}
+	if err := writeSet(promotion.Archived, info, map[string]TranslationSetContent{lang: active}); err != nil {
+		return TranslationPromotion{}, err
+	}
+
+	if err := activeTranslationBackend().ReplaceLanguage(lang, content, versions, author); err != nil {
+		os.RemoveAll(filepath.Join(translationSetsDir(), promotion.Archived))
+		return TranslationPromotion{}, err
+	}
+
+	if _, err := ReloadTranslations(); err != nil {
+		return TranslationPromotion{}, fmt.Errorf("translation set promoted but reload failed: %v", err)
+	}
+	return promotion, nil
+}
+
+// translationSetMatcher returns a lookup using the dictionaries of a set, for previews with a non-active set
+// Rules and the glossary are shared by all sets
+func translationSetMatcher(name string, lang string) (func(turkishText string, itemCode string) TranslationMatch, error) {
+	if err := checkSetName(name, true); err != nil {
+		return nil, err
+	}
+	if name == ActiveTranslationSet {
+		return func(turkishText string, itemCode string) TranslationMatch {
+			return MatchTranslation(lang, turkishText, itemCode)
+		}, nil
+	}
+
+	reader, err := setLanguageReader(name, lang)
+	if err != nil {
+		return nil, err
+	}
+	dict, err := setDictionaries.get(name, lang, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	// The set dictionary is never swapped by reloads, it needs no translationMutex
+	order := TranslationOrder()
+	return func(turkishText string, itemCode string) TranslationMatch {
+		return dict.lookupInOrder(order, lang, turkishText, itemCode)
+	}, nil
+}
+
+// setDictionaryCache holds the dictionaries built for set previews, by set and language
+type setDictionaryCache struct {
+	mutex   sync.Mutex
+	entries map[string]cachedSetDictionary
+}
+
+// cachedSetDictionary is a set dictionary with the versions of the set files it was built from
+type cachedSetDictionary struct {
+	versions string
+	dict     *dictionary
+}
+
+var setDictionaries setDictionaryCache
+
+// get returns the dictionary of a set, rebuilt when a dictionary file of the set changed
+func (c *setDictionaryCache) get(name string, lang string, reader translationSetReader) (*dictionary, error) {
+	versions, err := reader.versions(lang)
+	if err != nil {
+		return nil, err
+	}
+
+	key := name + "/" + lang
+	c.mutex.Lock()
+	cached, exists := c.entries[key]
+	c.mutex.Unlock()
+	if exists && cached.versions == versions {
+		return cached.dict, nil
+	}
+
+	dict, err := readDictionary(reader, lang, currentNormalization())
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	if c.entries == nil {
+		c.entries = make(map[string]cachedSetDictionary)
+	}
+	c.entries[key] = cachedSetDictionary{versions: versions, dict: dict}
+	c.mutex.Unlock()
+	return dict, nil
+}
+
+// clear drops the cached dictionaries, on reload: the sets share the rule, glossary and value files and the normalization
+func (c *setDictionaryCache) clear() {
+	c.mutex.Lock()
+	c.entries = nil
+	c.mutex.Unlock()
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
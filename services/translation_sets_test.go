@@ -0,0 +1,52 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTranslationSetMatcher(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Cleanup(setDictionaries.clear)
+
+	dir := filepath.Join(translationSetsDir(), "draft")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(content string) {
+		if err := os.WriteFile(filepath.Join(dir, "tr-to-cn.json"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	translate := func(name string) string {
+		t.Helper()
+		match, err := translationSetMatcher("draft", "cn")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return match(name, "").Text
+	}
+
+	write(`{"Somun": "螺母"}`)
+	if got := translate("Somun"); got != "螺母" {
+		t.Errorf("Somun = %q, want 螺母", got)
+	}
+	cached := setDictionaries.entries["draft/cn"].dict
+	if got := translate("SOMUN"); got != "螺母" || setDictionaries.entries["draft/cn"].dict != cached {
+		t.Errorf("SOMUN = %q, want 螺母 from the cached dictionary", got)
+	}
+
+	// A changed set file is picked up on the next preview
+	write(`{"Somun": "六角螺母"}`)
+	if got := translate("Somun"); got != "六角螺母" {
+		t.Errorf("Somun after the change = %q, want 六角螺母", got)
+	}
+
+	for _, tt := range []struct{ name, lang string }{{"missing", "cn"}, {"draft", "en"}} {
+		if _, err := translationSetMatcher(tt.name, tt.lang); !errors.Is(err, ErrTranslationSetNotFound) {
+			t.Errorf("translationSetMatcher(%s, %s) error = %v, want ErrTranslationSetNotFound", tt.name, tt.lang, err)
+		}
+	}
+}
@@ -0,0 +1,338 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"resco/db"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// translationSheetHeader is the header row of exported translation sheets
+var translationSheetHeader = []string{"source", "target", "scope", "key", "usage-count", "sample-codes"}
+
+// sheetColumnAliases maps header names accepted on import to their column
+// "name" lets the missing translations export be filled in and imported as it is
+var sheetColumnAliases = map[string]string{
+	"source": "source",
+	"name":   "source",
+	"target": "target",
+	"scope":  "scope",
+	"key":    "key",
+	"prefix": "key",
+	"code":   "key",
+}
+
+// TranslationSheetRow is one dictionary entry of a translation sheet
+// Key is the prefix for ScopePrefix and the item code for ScopeCode
+type TranslationSheetRow struct {
+	Line        int      `json:"line,omitempty"`
+	Source      string   `json:"source"`
+	Target      string   `json:"target"`
+	Scope       string   `json:"scope"`
+	Key         string   `json:"key,omitempty"`
+	UsageCount  *int     `json:"usage-count,omitempty"`
+	SampleCodes []string `json:"sample-codes,omitempty"`
+}
+
+// TranslationSheetChange is an entry the import creates or updates
+type TranslationSheetChange struct {
+	Line      int    `json:"line"`
+	Scope     string `json:"scope"`
+	Key       string `json:"key,omitempty"`
+	Source    string `json:"source,omitempty"`
+	Target    string `json:"target"`
+	OldTarget string `json:"old-target,omitempty"`
+}
+
+// TranslationImportResult is the diff of an imported sheet against the current dictionaries
+type TranslationImportResult struct {
+	Language  string                   `json:"language"`
+	Rows      int                      `json:"rows"`
+	Added     []TranslationSheetChange `json:"added"`
+	Changed   []TranslationSheetChange `json:"changed"`
+	Unchanged int                      `json:"unchanged"`
+	Skipped   int                      `json:"skipped"`
+	Errors    []string                 `json:"errors,omitempty"`
+	Applied   bool                     `json:"applied"`
+}
+
+// ExportTranslations returns all dictionary entries of a language as sheet rows
+// With a connection, each row gets the number of catalog items it translates and a few of their codes
+func ExportTranslations(ctx context.Context, conn *db.Connection, lang string) ([]TranslationSheetRow, error) {
+	backend := activeTranslationBackend()
+
+	direct, _, err := backend.ReadDirect(lang)
+	if err != nil {
+		return nil, err
+	}
+	fallback, _, err := backend.ReadFallback(lang)
+	if err != nil {
+		return nil, err
+	}
+	codes, _, err := backend.ReadCode(lang)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []TranslationSheetRow
+	for source, target := range direct {
+		rows = append(rows, TranslationSheetRow{Source: source, Target: target, Scope: ScopeDirect})
+	}
+	for prefix, entries := range fallback {
+		for source, target := range entries {
+			rows = append(rows, TranslationSheetRow{Source: source, Target: target, Scope: ScopePrefix, Key: prefix})
+		}
+	}
+	for code, target := range codes {
+		rows = append(rows, TranslationSheetRow{Target: target, Scope: ScopeCode, Key: code})
+	}
+
+	if conn != nil {
+		items, err := getCatalogItems(ctx, conn)
+		if err != nil {
+			return nil, err
+		}
+		countUsage(rows, items, prefixLengths(fallback))
+	}
+
+	scopeOrder := map[string]int{ScopeDirect: 0, ScopePrefix: 1, ScopeCode: 2}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Scope != rows[j].Scope {
+			return scopeOrder[rows[i].Scope] < scopeOrder[rows[j].Scope]
+		}
+		if rows[i].Key != rows[j].Key {
+			return rows[i].Key < rows[j].Key
+		}
+		return rows[i].Source < rows[j].Source
+	})
+	return rows, nil
+}
+
+// countUsage sets the usage count and sample codes of each row from the catalog items
+// Names are compared the way lookups compare them, normalized when normalization is enabled
+func countUsage(rows []TranslationSheetRow, items []catalogItem, lengths []int) {
+	opts := currentNormalization()
+	nameKey := func(name string) string {
+		if !opts.Enabled {
+			return name
+		}
+		return NormalizeName(name, opts.FoldDiacritics)
+	}
+	usageKey := func(scope, key, name string) string {
+		return scope + "\x00" + key + "\x00" + name
+	}
+
+	counts := make(map[string]int)
+	samples := make(map[string][]string)
+	use := func(k, code string) {
+		counts[k]++
+		if len(samples[k]) < maxSampleCodes {
+			samples[k] = append(samples[k], code)
+		}
+	}
+
+	for _, item := range items {
+		name := nameKey(item.Name)
+		use(usageKey(ScopeDirect, "", name), item.Code)
+		use(usageKey(ScopeCode, item.Code, ""), item.Code)
+		for _, length := range lengths {
+			if len(item.Code) >= length {
+				use(usageKey(ScopePrefix, item.Code[:length], name), item.Code)
+			}
+		}
+	}
+
+	for i := range rows {
+		k := usageKey(rows[i].Scope, rows[i].Key, "")
+		if rows[i].Scope != ScopeCode {
+			k = usageKey(rows[i].Scope, rows[i].Key, nameKey(rows[i].Source))
+		}
+		count := counts[k]
+		rows[i].UsageCount = &count
+		rows[i].SampleCodes = samples[k]
+	}
+}
+
+// WriteTranslationSheet writes sheet rows as CSV or XLSX
+func WriteTranslationSheet(w io.Writer, format string, rows []TranslationSheetRow) error {
+	records := [][]string{translationSheetHeader}
+	for _, row := range rows {
+		usage := ""
+		if row.UsageCount != nil {
+			usage = strconv.Itoa(*row.UsageCount)
+		}
+		records = append(records, []string{row.Source, row.Target, row.Scope, row.Key, usage, strings.Join(row.SampleCodes, " ")})
+	}
+	return writeSpreadsheet(w, format, records)
+}
+
+// ParseTranslationSheet reads the rows of a CSV or XLSX translation sheet
+// Columns are found by header name, rows without a scope are direct translations
+func ParseTranslationSheet(data []byte, format string) ([]TranslationSheetRow, error) {
+	records, err := readSpreadsheet(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTranslation, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: sheet is empty", ErrInvalidTranslation)
+	}
+
+	columns := make(map[string]int)
+	for i, header := range records[0] {
+		if column, exists := sheetColumnAliases[strings.ToLower(strings.TrimSpace(header))]; exists {
+			if _, duplicate := columns[column]; !duplicate {
+				columns[column] = i
+			}
+		}
+	}
+	if _, exists := columns["target"]; !exists {
+		return nil, fmt.Errorf("%w: sheet has no target column", ErrInvalidTranslation)
+	}
+	if _, exists := columns["source"]; !exists {
+		if _, exists := columns["key"]; !exists {
+			return nil, fmt.Errorf("%w: sheet has no source or key column", ErrInvalidTranslation)
+		}
+	}
+
+	cell := func(record []string, column string) string {
+		i, exists := columns[column]
+		if !exists || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var rows []TranslationSheetRow
+	for i, record := range records[1:] {
+		row := TranslationSheetRow{
+			Line:   i + 2,
+			Source: cell(record, "source"),
+			Target: cell(record, "target"),
+			Scope:  strings.ToLower(cell(record, "scope")),
+			Key:    cell(record, "key"),
+		}
+		if row.Source == "" && row.Target == "" && row.Key == "" {
+			continue
+		}
+		if row.Scope == "" {
+			row.Scope = ScopeDirect
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// ImportTranslations validates sheet rows and compares them with the current dictionaries of a language
+// Unless dryRun is set, all new and changed entries are written in one batch and the dictionaries are reloaded
+// Rows without a target are skipped, entries missing from the sheet are kept
+func ImportTranslations(lang string, rows []TranslationSheetRow, author string, dryRun bool) (TranslationImportResult, error) {
+	result := TranslationImportResult{
+		Language: lang,
+		Rows:     len(rows),
+		Added:    []TranslationSheetChange{},
+		Changed:  []TranslationSheetChange{},
+	}
+	if err := checkLanguages([]string{lang}); err != nil {
+		return result, err
+	}
+
+	translationWriteMutex.Lock()
+	defer translationWriteMutex.Unlock()
+
+	backend := activeTranslationBackend()
+	direct, directVersion, err := backend.ReadDirect(lang)
+	if err != nil {
+		return result, err
+	}
+	fallback, fallbackVersion, err := backend.ReadFallback(lang)
+	if err != nil {
+		return result, err
+	}
+	codes, codeVersion, err := backend.ReadCode(lang)
+	if err != nil {
+		return result, err
+	}
+	currentVersions := map[string]string{ScopeDirect: directVersion, ScopePrefix: fallbackVersion, ScopeCode: codeVersion}
+
+	seen := make(map[string]TranslationSheetRow)
+	versions := make(map[string]string)
+	var changes []TranslationChange
+	for _, row := range rows {
+		if row.Target == "" {
+			result.Skipped++
+			continue
+		}
+
+		var current string
+		var exists bool
+		switch row.Scope {
+		case ScopeDirect:
+			if row.Source == "" {
+				result.Errors = append(result.Errors, fmt.Sprintf("line %d: source is required", row.Line))
+				continue
+			}
+			row.Key = ""
+			current, exists = direct[row.Source]
+		case ScopePrefix:
+			if row.Source == "" || row.Key == "" {
+				result.Errors = append(result.Errors, fmt.Sprintf("line %d: source and key (prefix) are required", row.Line))
+				continue
+			}
+			current, exists = fallback[row.Key][row.Source]
+		case ScopeCode:
+			if row.Key == "" {
+				result.Errors = append(result.Errors, fmt.Sprintf("line %d: key (item code) is required", row.Line))
+				continue
+			}
+			row.Source = ""
+			current, exists = codes[row.Key]
+		default:
+			result.Errors = append(result.Errors, fmt.Sprintf("line %d: unknown scope %q", row.Line, row.Scope))
+			continue
+		}
+
+		id := row.Scope + "\x00" + row.Key + "\x00" + row.Source
+		if previous, duplicate := seen[id]; duplicate {
+			if previous.Target != row.Target {
+				result.Errors = append(result.Errors, fmt.Sprintf("line %d: conflicts with line %d (%q vs %q)", row.Line, previous.Line, row.Target, previous.Target))
+			}
+			continue
+		}
+		seen[id] = row
+
+		change := TranslationSheetChange{Line: row.Line, Scope: row.Scope, Key: row.Key, Source: row.Source, Target: row.Target}
+		switch {
+		case !exists:
+			result.Added = append(result.Added, change)
+		case current != row.Target:
+			change.OldTarget = current
+			result.Changed = append(result.Changed, change)
+		default:
+			result.Unchanged++
+			continue
+		}
+
+		versions[row.Scope] = currentVersions[row.Scope]
+		changes = append(changes, TranslationChange{Language: lang, Scope: row.Scope, Key: row.Key, Source: row.Source, Target: row.Target, Author: author})
+	}
+
+	if len(result.Errors) > 0 {
+		return result, fmt.Errorf("%w: sheet has %d invalid rows", ErrInvalidTranslation, len(result.Errors))
+	}
+	if dryRun || len(changes) == 0 {
+		return result, nil
+	}
+
+	if err := backend.PutBatch(lang, changes, versions); err != nil {
+		return result, err
+	}
+	result.Applied = true
+
+	if _, err := ReloadTranslations(); err != nil {
+		return result, fmt.Errorf("translations imported but reload failed: %v", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,652 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"resco/db"
+	"sort"
+	"time"
+)
+
+// DefaultTranslationTable is the table used by the SQL translation backend
+const DefaultTranslationTable = "RESCO_TRANSLATIONS"
+
+// sqlTranslationTimeout limits a single operation of the SQL translation backend
+const sqlTranslationTimeout = 30 * time.Second
+
+// Translation history actions
+const (
+	HistoryCreate  = "create"
+	HistoryUpdate  = "update"
+	HistoryDelete  = "delete"
+	HistoryImport  = "import"
+	HistoryPromote = "promote"
+)
+
+// ErrHistoryUnavailable is returned when the translation backend keeps no history
+var ErrHistoryUnavailable = errors.New("translation history is only available with the sql translation store")
+
+// SQLTranslationBackend stores the translation dictionaries in a database table,
+// every change is recorded in a history table with its author
+// Deleted entries are kept with status "deleted" so their history stays attached
+type SQLTranslationBackend struct {
+	conn         *db.Connection
+	table        string
+	historyTable string
+}
+
+// TranslationHistoryFilter selects translation history entries, empty fields match everything
+type TranslationHistoryFilter struct {
+	Language string
+	Scope    string
+	Key      string
+	Source   string
+	Limit    int
+}
+
+// TranslationHistoryEntry is one recorded change of a translation
+type TranslationHistoryEntry struct {
+	ID            int       `json:"id"`
+	TranslationID int       `json:"translation-id"`
+	Language      string    `json:"lang"`
+	Scope         string    `json:"scope"`
+	Key           string    `json:"key,omitempty"`
+	Source        string    `json:"source,omitempty"`
+	Action        string    `json:"action"`
+	OldTarget     *string   `json:"old-target"`
+	NewTarget     *string   `json:"new-target"`
+	ChangedBy     string    `json:"changed-by"`
+	ChangedAt     time.Time `json:"changed-at"`
+}
+
+// TranslationImportStats counts the entries of one language written by an import
+type TranslationImportStats struct {
+	Created   int `json:"created"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+}
+
+// translationRow is an active entry of the translation table
+type translationRow struct {
+	key    string
+	source string
+	target string
+}
+
+// queryer is implemented by *sql.DB and *sql.Tx
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// NewSQLTranslationBackend returns a backend using table (and table_HISTORY) in the company database
+func NewSQLTranslationBackend(conn *db.Connection, table string) (*SQLTranslationBackend, error) {
+	if table == "" {
+		table = DefaultTranslationTable
+	}
+	if !columnPattern.MatchString(table) {
+		return nil, fmt.Errorf("invalid translation table name: %q", table)
+	}
+
+	return &SQLTranslationBackend{
+		conn:         conn,
+		table:        conn.Table(table),
+		historyTable: conn.Table(table + "_HISTORY"),
+	}, nil
+}
+
+// EnsureSchema creates the translation and history tables if they do not exist
+// Source texts and scope values use a binary collation: the default one is case insensitive and would merge
+// entries like "Toz borusu" and "Toz Borusu" in the unique constraint and lookups
+func (b *SQLTranslationBackend) EnsureSchema(ctx context.Context) error {
+	schema := fmt.Sprintf(`
+	IF OBJECT_ID('%[1]s', 'U') IS NULL
+	CREATE TABLE %[1]s (
+		ID INT IDENTITY(1,1) PRIMARY KEY,
+		LANG VARCHAR(16) NOT NULL,
+		SCOPE VARCHAR(10) NOT NULL,
+		SCOPE_VALUE VARCHAR(24) COLLATE Latin1_General_100_BIN2 NOT NULL DEFAULT '',
+		SOURCE_TEXT NVARCHAR(400) COLLATE Latin1_General_100_BIN2 NOT NULL DEFAULT '',
+		TARGET_TEXT NVARCHAR(400) NOT NULL,
+		STATUS VARCHAR(10) NOT NULL DEFAULT 'active',
+		CREATED_BY NVARCHAR(100) NOT NULL,
+		CREATED_AT DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME(),
+		UPDATED_BY NVARCHAR(100) NOT NULL,
+		UPDATED_AT DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME(),
+		CONSTRAINT UQ_%[3]s UNIQUE (LANG, SCOPE, SCOPE_VALUE, SOURCE_TEXT)
+	);
+
+	IF OBJECT_ID('%[2]s', 'U') IS NULL
+	CREATE TABLE %[2]s (
+		ID INT IDENTITY(1,1) PRIMARY KEY,
+		TRANSLATION_ID INT NOT NULL,
+		ACTION VARCHAR(10) NOT NULL,
+		OLD_TARGET NVARCHAR(400) NULL,
+		NEW_TARGET NVARCHAR(400) NULL,
+		CHANGED_BY NVARCHAR(100) NOT NULL,
+		CHANGED_AT DATETIME2 NOT NULL DEFAULT SYSUTCDATETIME()
+	);
+	`, b.table, b.historyTable, uniqueConstraintSuffix(b.table))
+
+	if _, err := b.conn.DB.ExecContext(ctx, schema); err != nil {
+		return fmt.Errorf("error creating translation tables: %v", err)
+	}
+	return nil
+}
+
+// uniqueConstraintSuffix derives a constraint name part from the quoted table name
+func uniqueConstraintSuffix(table string) string {
+	var suffix []rune
+	for _, r := range table {
+		if r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			suffix = append(suffix, r)
+		}
+	}
+	return string(suffix)
+}
+
+func (b *SQLTranslationBackend) Name() string {
+	return "table " + b.table
+}
+
+// Languages returns the languages with active entries, Chinese is always included
+func (b *SQLTranslationBackend) Languages() ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), sqlTranslationTimeout)
+	defer cancel()
+
+	rows, err := b.conn.DB.QueryContext(ctx, fmt.Sprintf("SELECT DISTINCT LANG FROM %s WHERE STATUS = 'active'", b.table))
+	if err != nil {
+		return nil, fmt.Errorf("error executing query: %v", err)
+	}
+	defer rows.Close()
+
+	languages := []string{DefaultLanguage}
+	for rows.Next() {
+		var lang string
+		if err := rows.Scan(&lang); err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		if lang != DefaultLanguage && ValidateLanguage(lang) == nil {
+			languages = append(languages, lang)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	sort.Strings(languages)
+	return languages, nil
+}
+
+func (b *SQLTranslationBackend) ReadDirect(lang string) (map[string]string, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), sqlTranslationTimeout)
+	defer cancel()
+
+	entries, version, err := b.readScope(ctx, b.conn.DB, lang, ScopeDirect, false)
+	if err != nil {
+		return nil, "", err
+	}
+	return entries.(map[string]string), version, nil
+}
+
+func (b *SQLTranslationBackend) ReadFallback(lang string) (map[string]map[string]string, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), sqlTranslationTimeout)
+	defer cancel()
+
+	entries, version, err := b.readScope(ctx, b.conn.DB, lang, ScopePrefix, false)
+	if err != nil {
+		return nil, "", err
+	}
+	return entries.(map[string]map[string]string), version, nil
+}
+
+func (b *SQLTranslationBackend) ReadCode(lang string) (map[string]string, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), sqlTranslationTimeout)
+	defer cancel()
+
+	entries, version, err := b.readScope(ctx, b.conn.DB, lang, ScopeCode, false)
+	if err != nil {
+		return nil, "", err
+	}
+	return entries.(map[string]string), version, nil
+}
+
+// readScope reads the active entries of one scope of a language and computes their version
+// The version is computed like the version of a dictionary file written by the API
+// With lock set, the rows stay locked until the transaction ends
+func (b *SQLTranslationBackend) readScope(ctx context.Context, q queryer, lang, scope string, lock bool) (interface{}, string, error) {
+	hint := ""
+	if lock {
+		hint = " WITH (UPDLOCK, HOLDLOCK)"
+	}
+	query := fmt.Sprintf(`SELECT SCOPE_VALUE, SOURCE_TEXT, TARGET_TEXT FROM %s%s
+		WHERE LANG = @lang AND SCOPE = @scope AND STATUS = 'active'`, b.table, hint)
+
+	rows, err := q.QueryContext(ctx, query, sql.Named("lang", lang), sql.Named("scope", scope))
+	if err != nil {
+		return nil, "", fmt.Errorf("error executing query: %v", err)
+	}
+	defer rows.Close()
+
+	var entries []translationRow
+	for rows.Next() {
+		var row translationRow
+		if err := rows.Scan(&row.key, &row.source, &row.target); err != nil {
+			return nil, "", fmt.Errorf("error scanning row: %v", err)
+		}
+		entries = append(entries, row)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	origin := fmt.Sprintf("%s (%s, %s)", b.table, lang, scope)
+	var dictionary interface{}
+	switch scope {
+	case ScopePrefix:
+		fallback := make(map[string]map[string]string)
+		for _, row := range entries {
+			if fallback[row.key] == nil {
+				fallback[row.key] = make(map[string]string)
+			}
+			fallback[row.key][row.source] = row.target
+		}
+		if err := validateFallbackTranslations(origin, fallback); err != nil {
+			return nil, "", err
+		}
+		dictionary = fallback
+	default:
+		flat := make(map[string]string)
+		for _, row := range entries {
+			if scope == ScopeCode {
+				flat[row.key] = row.target
+			} else {
+				flat[row.source] = row.target
+			}
+		}
+		if err := validateTranslations(origin, flat); err != nil {
+			return nil, "", err
+		}
+		dictionary = flat
+	}
+
+	data, err := marshalDictionary(dictionary)
+	if err != nil {
+		return nil, "", err
+	}
+	return dictionary, dictionaryVersion(data), nil
+}
+
+// Put creates or updates an entry and records the change
+func (b *SQLTranslationBackend) Put(change TranslationChange) (string, error) {
+	return b.write(change, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := b.upsert(ctx, tx, change, "")
+		return err
+	})
+}
+
+// Delete marks an entry as deleted and records the change
+func (b *SQLTranslationBackend) Delete(change TranslationChange) (string, error) {
+	return b.write(change, func(ctx context.Context, tx *sql.Tx) error {
+		return b.remove(ctx, tx, change, HistoryDelete)
+	})
+}
+
+// remove marks the row of a change as deleted and records it with action
+func (b *SQLTranslationBackend) remove(ctx context.Context, tx *sql.Tx, change TranslationChange, action string) error {
+	id, oldTarget, status, err := b.findEntry(ctx, tx, change)
+	if err != nil {
+		return err
+	}
+	if id == 0 || status != "active" {
+		return fmt.Errorf("%w: %s", ErrTranslationNotFound, changeName(change))
+	}
+
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(`UPDATE %s SET STATUS = 'deleted', UPDATED_BY = @author, UPDATED_AT = SYSUTCDATETIME() WHERE ID = @id`, b.table),
+		sql.Named("author", change.Author), sql.Named("id", id))
+	if err != nil {
+		return fmt.Errorf("error deleting translation: %v", err)
+	}
+	return b.recordHistory(ctx, tx, id, action, &oldTarget, nil, change.Author)
+}
+
+// PutBatch writes several entries of a language in one transaction after checking the versions of their scopes
+func (b *SQLTranslationBackend) PutBatch(lang string, changes []TranslationChange, versions map[string]string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), sqlTranslationTimeout)
+	defer cancel()
+
+	tx, err := b.conn.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	for scope, version := range versions {
+		_, currentVersion, err := b.readScope(ctx, tx, lang, scope, true)
+		if err != nil {
+			return err
+		}
+		if version != "*" && version != currentVersion {
+			return fmt.Errorf("%w: %s expected version %s, current version is %s", ErrVersionMismatch, scope, version, currentVersion)
+		}
+	}
+
+	for _, change := range changes {
+		change.Language = lang
+		if _, err := b.upsert(ctx, tx, change, HistoryImport); err != nil {
+			return fmt.Errorf("error importing %s: %v", changeName(change), err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing import: %v", err)
+	}
+	return nil
+}
+
+// ReplaceLanguage replaces the active entries of a language in one transaction, every change is recorded as "promote"
+func (b *SQLTranslationBackend) ReplaceLanguage(lang string, content TranslationSetContent, versions map[string]string, author string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), sqlTranslationTimeout)
+	defer cancel()
+
+	tx, err := b.conn.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var current TranslationSetContent
+	for _, scope := range []string{ScopeDirect, ScopePrefix, ScopeCode} {
+		entries, currentVersion, err := b.readScope(ctx, tx, lang, scope, true)
+		if err != nil {
+			return err
+		}
+		if version, exists := versions[scope]; exists && version != "*" && version != currentVersion {
+			return fmt.Errorf("%w: %s expected version %s, current version is %s", ErrVersionMismatch, scope, version, currentVersion)
+		}
+		switch scope {
+		case ScopeDirect:
+			current.Direct = entries.(map[string]string)
+		case ScopePrefix:
+			current.Fallback = entries.(map[string]map[string]string)
+		case ScopeCode:
+			current.Code = entries.(map[string]string)
+		}
+	}
+
+	wanted := make(map[string]bool)
+	for _, change := range content.changes(lang) {
+		change.Author = author
+		wanted[change.Scope+"\x00"+change.Key+"\x00"+change.Source] = true
+		if _, err := b.upsert(ctx, tx, change, HistoryPromote); err != nil {
+			return fmt.Errorf("error promoting %s: %v", changeName(change), err)
+		}
+	}
+	for _, change := range current.changes(lang) {
+		if wanted[change.Scope+"\x00"+change.Key+"\x00"+change.Source] {
+			continue
+		}
+		change.Author = author
+		if err := b.remove(ctx, tx, change, HistoryPromote); err != nil {
+			return fmt.Errorf("error promoting %s: %v", changeName(change), err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing promotion: %v", err)
+	}
+	return nil
+}
+
+// write runs a change in a transaction after checking the version of its scope, and returns the new version
+func (b *SQLTranslationBackend) write(change TranslationChange, apply func(ctx context.Context, tx *sql.Tx) error) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), sqlTranslationTimeout)
+	defer cancel()
+
+	tx, err := b.conn.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, currentVersion, err := b.readScope(ctx, tx, change.Language, change.Scope, true)
+	if err != nil {
+		return "", err
+	}
+	if change.Version != "*" && change.Version != currentVersion {
+		return "", fmt.Errorf("%w: expected version %s, current version is %s", ErrVersionMismatch, change.Version, currentVersion)
+	}
+
+	if err := apply(ctx, tx); err != nil {
+		return "", err
+	}
+
+	_, newVersion, err := b.readScope(ctx, tx, change.Language, change.Scope, false)
+	if err != nil {
+		return "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("error committing translation change: %v", err)
+	}
+	return newVersion, nil
+}
+
+// findEntry returns the id, target and status of the row of a change, id is 0 if there is none
+func (b *SQLTranslationBackend) findEntry(ctx context.Context, q queryer, change TranslationChange) (int, string, string, error) {
+	var id int
+	var target, status string
+	err := q.QueryRowContext(ctx, fmt.Sprintf(`SELECT ID, TARGET_TEXT, STATUS FROM %s WITH (UPDLOCK, HOLDLOCK)
+		WHERE LANG = @lang AND SCOPE = @scope AND SCOPE_VALUE = @key AND SOURCE_TEXT = @source`, b.table),
+		sql.Named("lang", change.Language), sql.Named("scope", change.Scope),
+		sql.Named("key", change.Key), sql.Named("source", change.Source)).Scan(&id, &target, &status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, "", "", nil
+	}
+	if err != nil {
+		return 0, "", "", fmt.Errorf("error reading translation: %v", err)
+	}
+	return id, target, status, nil
+}
+
+// upsert creates, updates or restores the row of a change and records it
+// action overrides the recorded action (e.g. "import"), returns the action taken or "" if nothing changed
+func (b *SQLTranslationBackend) upsert(ctx context.Context, tx *sql.Tx, change TranslationChange, action string) (string, error) {
+	id, oldTarget, status, err := b.findEntry(ctx, tx, change)
+	if err != nil {
+		return "", err
+	}
+
+	if id == 0 {
+		err = tx.QueryRowContext(ctx, fmt.Sprintf(`INSERT INTO %s (LANG, SCOPE, SCOPE_VALUE, SOURCE_TEXT, TARGET_TEXT, STATUS, CREATED_BY, UPDATED_BY)
+			OUTPUT INSERTED.ID VALUES (@lang, @scope, @key, @source, @target, 'active', @author, @author)`, b.table),
+			sql.Named("lang", change.Language), sql.Named("scope", change.Scope), sql.Named("key", change.Key),
+			sql.Named("source", change.Source), sql.Named("target", change.Target), sql.Named("author", change.Author)).Scan(&id)
+		if err != nil {
+			return "", fmt.Errorf("error creating translation: %v", err)
+		}
+		if action == "" {
+			action = HistoryCreate
+		}
+		return HistoryCreate, b.recordHistory(ctx, tx, id, action, nil, &change.Target, change.Author)
+	}
+
+	if status == "active" && oldTarget == change.Target {
+		return "", nil
+	}
+
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(`UPDATE %s SET TARGET_TEXT = @target, STATUS = 'active', UPDATED_BY = @author, UPDATED_AT = SYSUTCDATETIME() WHERE ID = @id`, b.table),
+		sql.Named("target", change.Target), sql.Named("author", change.Author), sql.Named("id", id))
+	if err != nil {
+		return "", fmt.Errorf("error updating translation: %v", err)
+	}
+
+	var previous *string
+	if status == "active" {
+		previous = &oldTarget
+	}
+	if action == "" {
+		action = HistoryUpdate
+	}
+	return HistoryUpdate, b.recordHistory(ctx, tx, id, action, previous, &change.Target, change.Author)
+}
+
+// recordHistory stores one change of a translation
+func (b *SQLTranslationBackend) recordHistory(ctx context.Context, tx *sql.Tx, id int, action string, oldTarget, newTarget *string, author string) error {
+	_, err := tx.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %s (TRANSLATION_ID, ACTION, OLD_TARGET, NEW_TARGET, CHANGED_BY) VALUES (@id, @action, @old, @new, @author)`, b.historyTable),
+		sql.Named("id", id), sql.Named("action", action), sql.Named("old", oldTarget), sql.Named("new", newTarget), sql.Named("author", author))
+	if err != nil {
+		return fmt.Errorf("error recording translation history: %v", err)
+	}
+	return nil
+}
+
+// Signature returns the number of rows and the latest change, plus the rule files which stay on disk
+func (b *SQLTranslationBackend) Signature() string {
+	ctx, cancel := context.WithTimeout(context.Background(), sqlTranslationTimeout)
+	defer cancel()
+
+	var count int
+	var lastUpdate sql.NullTime
+	err := b.conn.DB.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*), MAX(UPDATED_AT) FROM %s", b.table)).Scan(&count, &lastUpdate)
+	if err != nil {
+		// Keep the previous translations while the database is unreachable
+		return "unavailable"
+	}
+	return fmt.Sprintf("%d:%d;%s", count, lastUpdate.Time.UnixNano(), translationFilesSignature())
+}
+
+// History returns the recorded changes matching the filter, newest first
+func (b *SQLTranslationBackend) History(filter TranslationHistoryFilter) ([]TranslationHistoryEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), sqlTranslationTimeout)
+	defer cancel()
+
+	if filter.Limit <= 0 {
+		filter.Limit = 100
+	}
+
+	query := fmt.Sprintf(`SELECT TOP (@limit) H.ID, H.TRANSLATION_ID, T.LANG, T.SCOPE, T.SCOPE_VALUE, T.SOURCE_TEXT,
+		H.ACTION, H.OLD_TARGET, H.NEW_TARGET, H.CHANGED_BY, H.CHANGED_AT
+		FROM %s H INNER JOIN %s T ON T.ID = H.TRANSLATION_ID
+		WHERE (@lang = '' OR T.LANG = @lang) AND (@scope = '' OR T.SCOPE = @scope)
+		AND (@key = '' OR T.SCOPE_VALUE = @key) AND (@source = '' OR T.SOURCE_TEXT = @source)
+		ORDER BY H.ID DESC`, b.historyTable, b.table)
+
+	rows, err := b.conn.DB.QueryContext(ctx, query, sql.Named("limit", filter.Limit),
+		sql.Named("lang", filter.Language), sql.Named("scope", filter.Scope),
+		sql.Named("key", filter.Key), sql.Named("source", filter.Source))
+	if err != nil {
+		return nil, fmt.Errorf("error executing query: %v", err)
+	}
+	defer rows.Close()
+
+	entries := []TranslationHistoryEntry{}
+	for rows.Next() {
+		var entry TranslationHistoryEntry
+		err := rows.Scan(&entry.ID, &entry.TranslationID, &entry.Language, &entry.Scope, &entry.Key, &entry.Source,
+			&entry.Action, &entry.OldTarget, &entry.NewTarget, &entry.ChangedBy, &entry.ChangedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+	return entries, nil
+}
+
+// ImportFiles copies the JSON dictionaries of every language in translate/ into the table
+// Entries that already exist with the same translation are left alone, the import runs in one transaction
+func (b *SQLTranslationBackend) ImportFiles(ctx context.Context, author string) (map[string]TranslationImportStats, error) {
+	files := fileTranslationBackend{}
+	languages, err := files.Languages()
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []TranslationChange
+	for _, lang := range languages {
+		direct, _, err := files.ReadDirect(lang)
+		if err != nil {
+			return nil, err
+		}
+		for source, target := range direct {
+			changes = append(changes, TranslationChange{Language: lang, Scope: ScopeDirect, Source: source, Target: target})
+		}
+
+		fallback, _, err := files.ReadFallback(lang)
+		if err != nil {
+			return nil, err
+		}
+		for prefix, prefixTranslations := range fallback {
+			for source, target := range prefixTranslations {
+				changes = append(changes, TranslationChange{Language: lang, Scope: ScopePrefix, Key: prefix, Source: source, Target: target})
+			}
+		}
+
+		codes, _, err := files.ReadCode(lang)
+		if err != nil {
+			return nil, err
+		}
+		for itemCode, target := range codes {
+			changes = append(changes, TranslationChange{Language: lang, Scope: ScopeCode, Key: itemCode, Target: target})
+		}
+	}
+
+	tx, err := b.conn.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	stats := make(map[string]TranslationImportStats)
+	for _, lang := range languages {
+		stats[lang] = TranslationImportStats{}
+	}
+	for _, change := range changes {
+		change.Author = author
+		action, err := b.upsert(ctx, tx, change, HistoryImport)
+		if err != nil {
+			return nil, fmt.Errorf("error importing %s: %v", changeName(change), err)
+		}
+
+		langStats := stats[change.Language]
+		switch action {
+		case HistoryCreate:
+			langStats.Created++
+		case HistoryUpdate:
+			langStats.Updated++
+		default:
+			langStats.Unchanged++
+		}
+		stats[change.Language] = langStats
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing import: %v", err)
+	}
+	return stats, nil
+}
+
+// GetTranslationHistory returns the recorded changes of the translations, newest first
+func GetTranslationHistory(filter TranslationHistoryFilter) ([]TranslationHistoryEntry, error) {
+	backend, ok := activeTranslationBackend().(*SQLTranslationBackend)
+	if !ok {
+		return nil, ErrHistoryUnavailable
+	}
+	return backend.History(filter)
+}
+
+// changeName describes the entry of a change in error messages
+func changeName(change TranslationChange) string {
+	switch change.Scope {
+	case ScopeCode:
+		return fmt.Sprintf("%s (item code, %s)", change.Key, change.Language)
+	case ScopePrefix:
+		return fmt.Sprintf("%s (prefix %s, %s)", change.Source, change.Key, change.Language)
+	}
+	return fmt.Sprintf("%s (%s)", change.Source, change.Language)
+}
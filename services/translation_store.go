@@ -0,0 +1,582 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	translationBackupDir   = "translate/backups"
+	translationBackupLimit = 50
+)
+
+var (
+	// ErrVersionMismatch is returned when a dictionary file changed since the caller read it
+	ErrVersionMismatch = errors.New("translation file was modified by someone else")
+	// ErrTranslationNotFound is returned when an entry to delete does not exist
+	ErrTranslationNotFound = errors.New("translation not found")
+	// ErrInvalidTranslation is returned for entries with missing fields
+	ErrInvalidTranslation = errors.New("invalid translation")
+	// ErrTranslationReloadFailed is returned with the new version when a change was saved but the dictionaries
+	// could not be reloaded, the previous translations stay in use until the next successful reload
+	ErrTranslationReloadFailed = errors.New("translation saved but reload failed")
+)
+
+// translationWriteMutex serializes writes to the translation backend and the reloads after them
+var translationWriteMutex sync.Mutex
+
+// Translation scopes, the dictionary an entry belongs to
+const (
+	ScopeDirect = "direct"
+	ScopePrefix = "prefix"
+	ScopeCode   = "code"
+)
+
+// TranslationChange is a single create, update or delete of a translation entry
+// Key is the prefix for ScopePrefix and the item code for ScopeCode, Source is empty for ScopeCode
+type TranslationChange struct {
+	Language string
+	Scope    string
+	Key      string
+	Source   string
+	Target   string
+	Version  string
+	Author   string
+}
+
+// TranslationBackend stores the translation dictionaries
+// Versions identify the content of one scope of a language, writes must reject a stale version ("*" skips the check)
+type TranslationBackend interface {
+	Name() string
+	Languages() ([]string, error)
+	ReadDirect(lang string) (map[string]string, string, error)
+	ReadFallback(lang string) (map[string]map[string]string, string, error)
+	ReadCode(lang string) (map[string]string, string, error)
+	Put(change TranslationChange) (string, error)
+	Delete(change TranslationChange) (string, error)
+	// PutBatch creates or updates several entries of a language at once, versions holds the expected version of each scope written
+	PutBatch(lang string, changes []TranslationChange, versions map[string]string) error
+	// ReplaceLanguage replaces all entries of a language, entries missing from content are deleted
+	ReplaceLanguage(lang string, content TranslationSetContent, versions map[string]string, author string) error
+	// Signature changes whenever the stored translations change, used to detect changes made elsewhere
+	Signature() string
+}
+
+var (
+	translationBackend      TranslationBackend = fileTranslationBackend{}
+	translationBackendMutex sync.RWMutex
+)
+
+// SetTranslationBackend selects where the translation dictionaries are stored, takes effect on the next reload
+func SetTranslationBackend(backend TranslationBackend) {
+	translationBackendMutex.Lock()
+	defer translationBackendMutex.Unlock()
+
+	translationBackend = backend
+}
+
+func activeTranslationBackend() TranslationBackend {
+	translationBackendMutex.RLock()
+	defer translationBackendMutex.RUnlock()
+
+	return translationBackend
+}
+
+// GetDirectTranslations returns the stored direct translations and their version
+func GetDirectTranslations(lang string) (map[string]string, string, error) {
+	return activeTranslationBackend().ReadDirect(lang)
+}
+
+// GetFallbackTranslations returns the stored prefix fallback translations and their version
+func GetFallbackTranslations(lang string) (map[string]map[string]string, string, error) {
+	return activeTranslationBackend().ReadFallback(lang)
+}
+
+// GetCodeTranslations returns the stored item code overrides and their version
+func GetCodeTranslations(lang string) (map[string]string, string, error) {
+	return activeTranslationBackend().ReadCode(lang)
+}
+
+// PutDirectTranslation creates or updates a direct translation and returns the new version
+// version must match the current version, "*" skips the check
+func PutDirectTranslation(lang, source, target, version, author string) (string, error) {
+	source = strings.TrimSpace(source)
+	target = strings.TrimSpace(target)
+	if source == "" || target == "" {
+		return "", fmt.Errorf("%w: source and target are required", ErrInvalidTranslation)
+	}
+
+	return applyTranslationChange(TranslationChange{Language: lang, Scope: ScopeDirect, Source: source, Target: target, Version: version, Author: author}, false)
+}
+
+// DeleteDirectTranslation removes a direct translation and returns the new version
+func DeleteDirectTranslation(lang, source, version, author string) (string, error) {
+	return applyTranslationChange(TranslationChange{Language: lang, Scope: ScopeDirect, Source: source, Version: version, Author: author}, true)
+}
+
+// PutCodeTranslation creates or updates the translation of a single item code and returns the new version
+func PutCodeTranslation(lang, itemCode, target, version, author string) (string, error) {
+	itemCode = strings.TrimSpace(itemCode)
+	target = strings.TrimSpace(target)
+	if itemCode == "" || target == "" {
+		return "", fmt.Errorf("%w: code and target are required", ErrInvalidTranslation)
+	}
+
+	return applyTranslationChange(TranslationChange{Language: lang, Scope: ScopeCode, Key: itemCode, Target: target, Version: version, Author: author}, false)
+}
+
+// DeleteCodeTranslation removes an item code override and returns the new version
+func DeleteCodeTranslation(lang, itemCode, version, author string) (string, error) {
+	return applyTranslationChange(TranslationChange{Language: lang, Scope: ScopeCode, Key: itemCode, Version: version, Author: author}, true)
+}
+
+// PutFallbackTranslation creates or updates a prefix fallback translation and returns the new version
+func PutFallbackTranslation(lang, prefix, source, target, version, author string) (string, error) {
+	prefix = strings.TrimSpace(prefix)
+	source = strings.TrimSpace(source)
+	target = strings.TrimSpace(target)
+	if prefix == "" || source == "" || target == "" {
+		return "", fmt.Errorf("%w: prefix, source and target are required", ErrInvalidTranslation)
+	}
+
+	return applyTranslationChange(TranslationChange{Language: lang, Scope: ScopePrefix, Key: prefix, Source: source, Target: target, Version: version, Author: author}, false)
+}
+
+// DeleteFallbackTranslation removes a prefix fallback translation and returns the new version
+func DeleteFallbackTranslation(lang, prefix, source, version, author string) (string, error) {
+	return applyTranslationChange(TranslationChange{Language: lang, Scope: ScopePrefix, Key: prefix, Source: source, Version: version, Author: author}, true)
+}
+
+// applyTranslationChange writes a change to the backend and reloads the dictionaries
+// A failed reload does not undo the change, the new version is returned with ErrTranslationReloadFailed
+func applyTranslationChange(change TranslationChange, remove bool) (string, error) {
+	// A missing file reads as an empty dictionary, writing to it would create a new language
+	if err := checkLanguages([]string{change.Language}); err != nil {
+		return "", err
+	}
+
+	translationWriteMutex.Lock()
+	defer translationWriteMutex.Unlock()
+
+	backend := activeTranslationBackend()
+
+	var version string
+	var err error
+	if remove {
+		version, err = backend.Delete(change)
+	} else {
+		version, err = backend.Put(change)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := ReloadTranslations(); err != nil {
+		return version, fmt.Errorf("%w: %v", ErrTranslationReloadFailed, err)
+	}
+	return version, nil
+}
+
+// fileTranslationBackend stores the dictionaries as JSON files in translate/
+type fileTranslationBackend struct{}
+
+func (fileTranslationBackend) Name() string {
+	return translationDir
+}
+
+func (fileTranslationBackend) Languages() ([]string, error) {
+	return discoverLanguages()
+}
+
+func (fileTranslationBackend) ReadDirect(lang string) (map[string]string, string, error) {
+	return readFlatDictionaryFile(directTranslationsFile(lang))
+}
+
+func (fileTranslationBackend) ReadFallback(lang string) (map[string]map[string]string, string, error) {
+	return readFallbackDictionaryFile(fallbackTranslationsFile(lang))
+}
+
+// readFallbackDictionaryFile reads and validates a prefix fallback file and returns its entries and version
+func readFallbackDictionaryFile(path string) (map[string]map[string]string, string, error) {
+	data, version, err := readDictionaryFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	entries, err := parseFallbackTranslations(path, data)
+	if err != nil {
+		return nil, "", err
+	}
+	return entries, version, nil
+}
+
+func (fileTranslationBackend) ReadCode(lang string) (map[string]string, string, error) {
+	return readFlatDictionaryFile(codeTranslationsFile(lang))
+}
+
+func (fileTranslationBackend) Put(change TranslationChange) (string, error) {
+	switch change.Scope {
+	case ScopeDirect:
+		return putDictionaryEntry(directTranslationsFile(change.Language), change.Source, change.Target, change.Version)
+	case ScopeCode:
+		return putDictionaryEntry(codeTranslationsFile(change.Language), change.Key, change.Target, change.Version)
+	case ScopePrefix:
+		return putFallbackEntry(fallbackTranslationsFile(change.Language), change.Key, change.Source, change.Target, change.Version)
+	}
+	return "", fmt.Errorf("%w: unknown scope %s", ErrInvalidTranslation, change.Scope)
+}
+
+func (fileTranslationBackend) Delete(change TranslationChange) (string, error) {
+	switch change.Scope {
+	case ScopeDirect:
+		return deleteDictionaryEntry(directTranslationsFile(change.Language), change.Source, change.Version)
+	case ScopeCode:
+		return deleteDictionaryEntry(codeTranslationsFile(change.Language), change.Key, change.Version)
+	case ScopePrefix:
+		return deleteFallbackEntry(fallbackTranslationsFile(change.Language), change.Key, change.Source, change.Version)
+	}
+	return "", fmt.Errorf("%w: unknown scope %s", ErrInvalidTranslation, change.Scope)
+}
+
+// PutBatch checks the versions and prepares the new content of every file before writing any of them
+func (fileTranslationBackend) PutBatch(lang string, changes []TranslationChange, versions map[string]string) error {
+	scoped := make(map[string][]TranslationChange)
+	for _, change := range changes {
+		if change.Scope != ScopeDirect && change.Scope != ScopePrefix && change.Scope != ScopeCode {
+			return fmt.Errorf("%w: unknown scope %s", ErrInvalidTranslation, change.Scope)
+		}
+		scoped[change.Scope] = append(scoped[change.Scope], change)
+	}
+
+	var updates []dictionaryFileUpdate
+	add := func(scope, path string, update func(data []byte) (interface{}, error)) {
+		if len(scoped[scope]) == 0 {
+			return
+		}
+		version, exists := versions[scope]
+		if !exists {
+			version = "*"
+		}
+		updates = append(updates, dictionaryFileUpdate{path: path, version: version, update: update})
+	}
+
+	add(ScopeDirect, directTranslationsFile(lang), flatDictionaryUpdate(directTranslationsFile(lang), scoped[ScopeDirect], func(c TranslationChange) string { return c.Source }))
+	add(ScopePrefix, fallbackTranslationsFile(lang), func(data []byte) (interface{}, error) {
+		var entries map[string]map[string]string
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("error parsing %s: %v", fallbackTranslationsFile(lang), err)
+		}
+		if entries == nil {
+			entries = make(map[string]map[string]string)
+		}
+		for _, change := range scoped[ScopePrefix] {
+			if entries[change.Key] == nil {
+				entries[change.Key] = make(map[string]string)
+			}
+			entries[change.Key][change.Source] = change.Target
+		}
+		return entries, nil
+	})
+	add(ScopeCode, codeTranslationsFile(lang), flatDictionaryUpdate(codeTranslationsFile(lang), scoped[ScopeCode], func(c TranslationChange) string { return c.Key }))
+
+	return updateDictionaryFiles(updates)
+}
+
+// ReplaceLanguage writes the three dictionary files of a language after checking all their versions
+func (fileTranslationBackend) ReplaceLanguage(lang string, content TranslationSetContent, versions map[string]string, author string) error {
+	var updates []dictionaryFileUpdate
+	for _, file := range []struct {
+		scope   string
+		path    string
+		entries interface{}
+	}{
+		{ScopeDirect, directTranslationsFile(lang), content.Direct},
+		{ScopePrefix, fallbackTranslationsFile(lang), content.Fallback},
+		{ScopeCode, codeTranslationsFile(lang), content.Code},
+	} {
+		version, exists := versions[file.scope]
+		if !exists {
+			version = "*"
+		}
+		entries := file.entries
+		updates = append(updates, dictionaryFileUpdate{path: file.path, version: version, update: func(data []byte) (interface{}, error) {
+			return entries, nil
+		}})
+	}
+	return updateDictionaryFiles(updates)
+}
+
+// flatDictionaryUpdate returns an update setting the targets of changes in a flat dictionary file
+func flatDictionaryUpdate(path string, changes []TranslationChange, key func(TranslationChange) string) func(data []byte) (interface{}, error) {
+	return func(data []byte) (interface{}, error) {
+		var entries map[string]string
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("error parsing %s: %v", path, err)
+		}
+		if entries == nil {
+			entries = make(map[string]string)
+		}
+		for _, change := range changes {
+			entries[key(change)] = change.Target
+		}
+		return entries, nil
+	}
+}
+
+func (fileTranslationBackend) Signature() string {
+	return translationFilesSignature()
+}
+
+// readFlatDictionaryFile reads and validates a flat dictionary file and returns its version
+func readFlatDictionaryFile(path string) (map[string]string, string, error) {
+	data, version, err := readDictionaryFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	entries, err := parseTranslations(path, data)
+	if err != nil {
+		return nil, "", err
+	}
+	return entries, version, nil
+}
+
+// putDictionaryEntry sets a key in a flat dictionary file
+func putDictionaryEntry(path, key, target, version string) (string, error) {
+	return updateDictionaryFile(path, version, func(data []byte) (interface{}, error) {
+		var entries map[string]string
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("error parsing %s: %v", path, err)
+		}
+		if entries == nil {
+			entries = make(map[string]string)
+		}
+		entries[key] = target
+		return entries, nil
+	})
+}
+
+// deleteDictionaryEntry removes a key from a flat dictionary file
+func deleteDictionaryEntry(path, key, version string) (string, error) {
+	return updateDictionaryFile(path, version, func(data []byte) (interface{}, error) {
+		var entries map[string]string
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("error parsing %s: %v", path, err)
+		}
+		if _, exists := entries[key]; !exists {
+			return nil, fmt.Errorf("%w: %s", ErrTranslationNotFound, key)
+		}
+		delete(entries, key)
+		return entries, nil
+	})
+}
+
+// putFallbackEntry sets a name under a prefix in a fallback dictionary file
+func putFallbackEntry(path, prefix, source, target, version string) (string, error) {
+	return updateDictionaryFile(path, version, func(data []byte) (interface{}, error) {
+		var entries map[string]map[string]string
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("error parsing %s: %v", path, err)
+		}
+		if entries == nil {
+			entries = make(map[string]map[string]string)
+		}
+		if entries[prefix] == nil {
+			entries[prefix] = make(map[string]string)
+		}
+		entries[prefix][source] = target
+		return entries, nil
+	})
+}
+
+// deleteFallbackEntry removes a name under a prefix from a fallback dictionary file
+// Prefixes without entries are removed from the file
+func deleteFallbackEntry(path, prefix, source, version string) (string, error) {
+	return updateDictionaryFile(path, version, func(data []byte) (interface{}, error) {
+		var entries map[string]map[string]string
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("error parsing %s: %v", path, err)
+		}
+		if _, exists := entries[prefix][source]; !exists {
+			return nil, fmt.Errorf("%w: %s (prefix %s)", ErrTranslationNotFound, source, prefix)
+		}
+		delete(entries[prefix], source)
+		if len(entries[prefix]) == 0 {
+			delete(entries, prefix)
+		}
+		return entries, nil
+	})
+}
+
+// readDictionaryFile reads a dictionary file and returns its content and version
+// A missing file is treated as an empty dictionary, so the first write creates it
+func readDictionaryFile(path string) ([]byte, string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		data = []byte("{}")
+	} else if err != nil {
+		return nil, "", fmt.Errorf("error reading %s: %v", path, err)
+	}
+	return data, dictionaryVersion(data), nil
+}
+
+// dictionaryVersion returns the version (content hash) of a dictionary file
+func dictionaryVersion(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// updateDictionaryFile applies an update to a dictionary file with optimistic concurrency
+// The current file is backed up, the new content is written to a temporary file and renamed over the original
+// The caller must hold translationWriteMutex
+func updateDictionaryFile(path string, version string, update func(data []byte) (interface{}, error)) (string, error) {
+	data, currentVersion, err := readDictionaryFile(path)
+	if err != nil {
+		return "", err
+	}
+	if version != "*" && version != currentVersion {
+		return "", fmt.Errorf("%w: expected version %s, current version is %s", ErrVersionMismatch, version, currentVersion)
+	}
+
+	entries, err := update(data)
+	if err != nil {
+		return "", err
+	}
+
+	newData, err := marshalDictionary(entries)
+	if err != nil {
+		return "", err
+	}
+
+	if err := backupDictionaryFile(path, data); err != nil {
+		return "", err
+	}
+
+	if err := writeFileAtomic(path, newData); err != nil {
+		return "", err
+	}
+
+	return dictionaryVersion(newData), nil
+}
+
+// dictionaryFileUpdate is one file of a batch written by updateDictionaryFiles
+type dictionaryFileUpdate struct {
+	path    string
+	version string
+	update  func(data []byte) (interface{}, error)
+}
+
+// updateDictionaryFiles applies updates to several dictionary files
+// Every file is read, version checked and encoded before the first one is replaced, so a rejected batch changes nothing
+// The caller must hold translationWriteMutex
+func updateDictionaryFiles(updates []dictionaryFileUpdate) error {
+	oldData := make([][]byte, len(updates))
+	newData := make([][]byte, len(updates))
+	for i, u := range updates {
+		data, currentVersion, err := readDictionaryFile(u.path)
+		if err != nil {
+			return err
+		}
+		if u.version != "*" && u.version != currentVersion {
+			return fmt.Errorf("%w: %s expected version %s, current version is %s", ErrVersionMismatch, u.path, u.version, currentVersion)
+		}
+
+		entries, err := u.update(data)
+		if err != nil {
+			return err
+		}
+		if newData[i], err = marshalDictionary(entries); err != nil {
+			return err
+		}
+		oldData[i] = data
+	}
+
+	for i, u := range updates {
+		if err := backupDictionaryFile(u.path, oldData[i]); err != nil {
+			return err
+		}
+	}
+	for i, u := range updates {
+		if err := writeFileAtomic(u.path, newData[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// marshalDictionary encodes a dictionary the way the files are written by hand: indented, without HTML escaping
+func marshalDictionary(entries interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(entries); err != nil {
+		return nil, fmt.Errorf("error encoding translations: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// writeFileAtomic writes data to a temporary file in the same directory and renames it over path
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("error creating temporary file: %v", err)
+	}
+	tmpName := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return fmt.Errorf("error writing temporary file: %v", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return fmt.Errorf("error syncing temporary file: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("error closing temporary file: %v", err)
+	}
+
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("error replacing %s: %v", path, err)
+	}
+	return nil
+}
+
+// backupDictionaryFile stores a timestamped copy of a dictionary file and prunes old backups
+func backupDictionaryFile(path string, data []byte) error {
+	if err := os.MkdirAll(translationBackupDir, 0755); err != nil {
+		return fmt.Errorf("error creating backup directory: %v", err)
+	}
+
+	base := strings.TrimSuffix(filepath.Base(path), ".json")
+	name := fmt.Sprintf("%s.%s.json", base, time.Now().Format("20060102T150405.000"))
+	if err := os.WriteFile(filepath.Join(translationBackupDir, name), data, 0644); err != nil {
+		return fmt.Errorf("error writing backup: %v", err)
+	}
+
+	// Keep only the latest backups of this file
+	backups, err := filepath.Glob(filepath.Join(translationBackupDir, base+".*.json"))
+	if err != nil {
+		return nil
+	}
+	sort.Strings(backups)
+	for len(backups) > translationBackupLimit {
+		os.Remove(backups[0])
+		backups = backups[1:]
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// useTranslationFiles chdirs to a temporary directory with the given dictionary files and loads them
+// The previously loaded dictionaries are restored when the test ends
+func useTranslationFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	translationMutex.Lock()
+	previousDictionaries, previousProfiles, previousLoaded, previousReload := dictionaries, profiles, translationsLoaded, lastReload
+	translationMutex.Unlock()
+	t.Cleanup(func() {
+		translationMutex.Lock()
+		dictionaries, profiles, translationsLoaded, lastReload = previousDictionaries, previousProfiles, previousLoaded, previousReload
+		translationMutex.Unlock()
+	})
+
+	if _, err := ReloadTranslations(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTranslationWritesRejectUnknownLanguages(t *testing.T) {
+	useTranslationFiles(t, map[string]string{directTranslationsFile("cn"): `{"Somun": "螺母"}`})
+
+	writes := map[string]func(lang string) error{
+		"put direct": func(lang string) error {
+			_, err := PutDirectTranslation(lang, "Pul", "Washer", "*", "test")
+			return err
+		},
+		"delete direct": func(lang string) error {
+			_, err := DeleteDirectTranslation(lang, "Somun", "*", "test")
+			return err
+		},
+		"put code": func(lang string) error {
+			_, err := PutCodeTranslation(lang, "A1", "Washer", "*", "test")
+			return err
+		},
+		"put fallback": func(lang string) error {
+			_, err := PutFallbackTranslation(lang, "8010", "Pul", "Washer", "*", "test")
+			return err
+		},
+		"import": func(lang string) error {
+			_, err := ImportTranslations(lang, []TranslationSheetRow{{Line: 2, Scope: ScopeDirect, Source: "Pul", Target: "Washer"}}, "test", false)
+			return err
+		},
+	}
+
+	for name, write := range writes {
+		if err := write("en"); !errors.Is(err, ErrUnknownLanguage) {
+			t.Errorf("%s: error = %v, want ErrUnknownLanguage", name, err)
+		}
+	}
+	for _, path := range []string{directTranslationsFile("en"), fallbackTranslationsFile("en"), codeTranslationsFile("en")} {
+		if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s was created: %v", path, err)
+		}
+	}
+	if HasLanguage("en") {
+		t.Error("en was added as a language")
+	}
+
+	if _, err := PutDirectTranslation("cn", "Pul", "垫圈", "*", "test"); err != nil {
+		t.Fatalf("put direct cn: %v", err)
+	}
+	if got, _ := TranslateTo("cn", "Pul", ""); got != "垫圈" {
+		t.Errorf("Pul = %q, want 垫圈", got)
+	}
+}
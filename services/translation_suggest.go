@@ -0,0 +1,412 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Suggestion review states
+const (
+	SuggestionPending  = "pending"
+	SuggestionApproved = "approved"
+	SuggestionRejected = "rejected"
+)
+
+const (
+	suggestionQueueSize     = 1000
+	suggestionTimeout       = 10 * time.Second
+	suggestionRetryInterval = time.Hour
+	suggestionFlushInterval = 10 * time.Second
+)
+
+var (
+	// ErrSuggestionNotFound is returned when a suggestion id does not exist
+	ErrSuggestionNotFound = errors.New("suggestion not found")
+	// ErrSuggestionReviewed is returned when a suggestion was already approved or rejected
+	ErrSuggestionReviewed = errors.New("suggestion was already reviewed")
+)
+
+// Translator proposes translations for names the dictionaries cannot translate
+// Proposals are never used directly, they wait in the review queue until approved
+type Translator interface {
+	Name() string
+	// Suggest returns a proposed translation of text into lang, ok is false when the translator has none
+	Suggest(ctx context.Context, lang string, text string, itemCode string) (translated string, ok bool, err error)
+}
+
+// TranslationSuggestion is a proposed direct translation waiting for review
+type TranslationSuggestion struct {
+	ID         int        `json:"id"`
+	Language   string     `json:"language"`
+	Source     string     `json:"source"`
+	Target     string     `json:"target"`
+	Provider   string     `json:"provider"`
+	ItemCodes  []string   `json:"item-codes"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"created-at"`
+	ReviewedBy string     `json:"reviewed-by,omitempty"`
+	ReviewedAt *time.Time `json:"reviewed-at,omitempty"`
+}
+
+type suggestionRequest struct {
+	lang string
+	item UntranslatedItem
+}
+
+// suggestionQueue holds the suggestions and the names waiting for a translator
+// Changes mark the queue dirty, flush writes it outside the mutex so BOM requests never wait for the disk
+type suggestionQueue struct {
+	mutex       sync.Mutex
+	translators []Translator
+	entries     []*TranslationSuggestion
+	index       map[string]*TranslationSuggestion
+	nextID      int
+	attempted   map[string]time.Time
+	requests    chan suggestionRequest
+	dirty       bool
+
+	// writeMutex orders the writes of the queue file
+	writeMutex sync.Mutex
+}
+
+var suggestions suggestionQueue
+
+// registeredTranslators are the translators that can be selected by name
+var registeredTranslators = map[string]Translator{
+	"glossary": GlossaryTranslator{},
+}
+
+// RegisterTranslator makes a translator (e.g. an external machine translation service) selectable by its name
+func RegisterTranslator(translator Translator) {
+	registeredTranslators[translator.Name()] = translator
+}
+
+// TranslatorsByName returns the registered translators with the given names, in that order
+func TranslatorsByName(names []string) ([]Translator, error) {
+	var translators []Translator
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		translator, exists := registeredTranslators[name]
+		if !exists {
+			return nil, fmt.Errorf("unknown translator: %s", name)
+		}
+		translators = append(translators, translator)
+	}
+	return translators, nil
+}
+
+// suggestionsFile stores the review queue, it is not a dictionary and is not watched
+func suggestionsFile() string {
+	return filepath.Join(translationDir, "suggestions.json")
+}
+
+// StartTranslationSuggestions loads the review queue and starts asking translators for names that stay untranslated
+// Translators are tried in order, the first suggestion is queued; without translators the queue can only be reviewed
+func StartTranslationSuggestions(translators []Translator) (stop func(), err error) {
+	entries, err := readSuggestionsFile(suggestionsFile())
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions.mutex.Lock()
+	suggestions.entries = entries
+	suggestions.index = make(map[string]*TranslationSuggestion)
+	suggestions.nextID = 1
+	for _, entry := range entries {
+		suggestions.index[suggestionKey(entry.Language, entry.Source)] = entry
+		if entry.ID >= suggestions.nextID {
+			suggestions.nextID = entry.ID + 1
+		}
+	}
+	suggestions.mutex.Unlock()
+
+	if len(translators) == 0 {
+		return func() {}, nil
+	}
+
+	requests := make(chan suggestionRequest, suggestionQueueSize)
+
+	suggestions.mutex.Lock()
+	suggestions.translators = translators
+	suggestions.attempted = make(map[string]time.Time)
+	suggestions.requests = requests
+	suggestions.mutex.Unlock()
+
+	done := make(chan struct{})
+	var once sync.Once
+
+	go func() {
+		ticker := time.NewTicker(suggestionFlushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case request := <-requests:
+				suggestions.process(request)
+				suggestions.flush()
+			case <-ticker.C:
+				suggestions.flush()
+			}
+		}
+	}()
+
+	return func() {
+		once.Do(func() {
+			suggestions.mutex.Lock()
+			suggestions.requests = nil
+			suggestions.mutex.Unlock()
+			close(done)
+			suggestions.flush()
+		})
+	}, nil
+}
+
+// requestSuggestions queues the untranslated and composed names of a BOM for the translators
+// Names already in the review queue or tried recently are skipped; when the queue is full names are dropped
+func requestSuggestions(lang string, items []UntranslatedItem) {
+	if len(items) == 0 {
+		return
+	}
+
+	suggestions.mutex.Lock()
+	defer suggestions.mutex.Unlock()
+
+	if suggestions.requests == nil {
+		return
+	}
+	for _, item := range items {
+		key := suggestionKey(lang, item.Name)
+		if existing := suggestions.find(key); existing != nil {
+			if existing.Status == SuggestionPending && addItemCode(existing, item.Code) {
+				suggestions.dirty = true
+			}
+			continue
+		}
+		if tried, exists := suggestions.attempted[key]; exists && time.Since(tried) < suggestionRetryInterval {
+			continue
+		}
+		suggestions.attempted[key] = time.Now()
+
+		select {
+		case suggestions.requests <- suggestionRequest{lang: lang, item: item}:
+		default:
+			delete(suggestions.attempted, key)
+		}
+	}
+}
+
+// process asks the translators for one name and queues the first suggestion
+func (q *suggestionQueue) process(request suggestionRequest) {
+	// The name may have been translated since it was queued, composed names still need a reviewed entry
+	if match := MatchTranslation(request.lang, request.item.Name, request.item.Code); match.Source != "" && match.Source != SourceComposed {
+		return
+	}
+
+	q.mutex.Lock()
+	translators := q.translators
+	q.mutex.Unlock()
+
+	for _, translator := range translators {
+		ctx, cancel := context.WithTimeout(context.Background(), suggestionTimeout)
+		translated, ok, err := translator.Suggest(ctx, request.lang, request.item.Name, request.item.Code)
+		cancel()
+		if err != nil {
+			log.Printf("Translator %s failed for %q: %v", translator.Name(), request.item.Name, err)
+			continue
+		}
+		if !ok || translated == "" {
+			continue
+		}
+
+		q.mutex.Lock()
+		key := suggestionKey(request.lang, request.item.Name)
+		if q.find(key) == nil {
+			entry := &TranslationSuggestion{
+				ID:        q.nextID,
+				Language:  request.lang,
+				Source:    request.item.Name,
+				Target:    translated,
+				Provider:  translator.Name(),
+				ItemCodes: []string{request.item.Code},
+				Status:    SuggestionPending,
+				CreatedAt: time.Now().UTC(),
+			}
+			q.entries = append(q.entries, entry)
+			q.index[key] = entry
+			q.nextID++
+			q.dirty = true
+		}
+		q.mutex.Unlock()
+		return
+	}
+}
+
+// find returns the suggestion for a language and name, the caller must hold the mutex
+func (q *suggestionQueue) find(key string) *TranslationSuggestion {
+	return q.index[key]
+}
+
+// flush writes the review queue if it changed, the entries are marshalled under the mutex and written outside it
+func (q *suggestionQueue) flush() {
+	q.writeMutex.Lock()
+	defer q.writeMutex.Unlock()
+
+	q.mutex.Lock()
+	if !q.dirty {
+		q.mutex.Unlock()
+		return
+	}
+	data, err := marshalDictionary(q.entries)
+	q.dirty = false
+	q.mutex.Unlock()
+
+	if err == nil {
+		err = writeFileAtomic(suggestionsFile(), data)
+	}
+	if err != nil {
+		log.Printf("Error saving translation suggestions: %v", err)
+		q.mutex.Lock()
+		q.dirty = true
+		q.mutex.Unlock()
+	}
+}
+
+// GetTranslationSuggestions returns the suggestions of a language with the given status, all when status is empty
+func GetTranslationSuggestions(lang string, status string) []TranslationSuggestion {
+	suggestions.mutex.Lock()
+	defer suggestions.mutex.Unlock()
+
+	result := []TranslationSuggestion{}
+	for _, entry := range suggestions.entries {
+		if (lang == "" || entry.Language == lang) && (status == "" || entry.Status == status) {
+			result = append(result, *entry)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+// ApproveTranslationSuggestion adds a suggestion to the direct dictionary, target replaces the suggested text when set
+// The suggestion is marked approved before the dictionary is written, outside the mutex: the write reloads the
+// dictionaries and BOM requests queue names under the same mutex. A failed write puts the suggestion back
+func ApproveTranslationSuggestion(id int, target string, author string) (TranslationSuggestion, error) {
+	suggestions.mutex.Lock()
+	entry, err := suggestions.pending(id)
+	if err != nil {
+		suggestions.mutex.Unlock()
+		return TranslationSuggestion{}, err
+	}
+	previous := *entry
+	if target == "" {
+		target = entry.Target
+	}
+	entry.Target = target
+	suggestions.review(entry, SuggestionApproved, author)
+	approved := *entry
+	suggestions.mutex.Unlock()
+
+	if _, err := PutDirectTranslation(approved.Language, approved.Source, target, "*", author); err != nil {
+		if !errors.Is(err, ErrTranslationReloadFailed) {
+			suggestions.mutex.Lock()
+			*entry = previous
+			suggestions.mutex.Unlock()
+			return TranslationSuggestion{}, err
+		}
+		// The entry is saved, the watcher picks it up once the dictionaries load again
+		log.Printf("Warning: suggestion %d approved: %v", id, err)
+	}
+
+	suggestions.flush()
+	return approved, nil
+}
+
+// RejectTranslationSuggestion marks a suggestion as rejected, the name is not suggested again
+func RejectTranslationSuggestion(id int, author string) (TranslationSuggestion, error) {
+	suggestions.mutex.Lock()
+	entry, err := suggestions.pending(id)
+	if err != nil {
+		suggestions.mutex.Unlock()
+		return TranslationSuggestion{}, err
+	}
+	suggestions.review(entry, SuggestionRejected, author)
+	rejected := *entry
+	suggestions.mutex.Unlock()
+
+	suggestions.flush()
+	return rejected, nil
+}
+
+// pending returns a suggestion waiting for review, the caller must hold the mutex
+func (q *suggestionQueue) pending(id int) (*TranslationSuggestion, error) {
+	for _, entry := range q.entries {
+		if entry.ID != id {
+			continue
+		}
+		if entry.Status != SuggestionPending {
+			return nil, fmt.Errorf("%w: %d is %s", ErrSuggestionReviewed, id, entry.Status)
+		}
+		return entry, nil
+	}
+	return nil, fmt.Errorf("%w: %d", ErrSuggestionNotFound, id)
+}
+
+// review records the decision on a suggestion, the caller must hold the mutex
+func (q *suggestionQueue) review(entry *TranslationSuggestion, status string, author string) {
+	now := time.Now().UTC()
+	entry.Status = status
+	entry.ReviewedBy = author
+	entry.ReviewedAt = &now
+	q.dirty = true
+}
+
+// readSuggestionsFile reads the review queue, a missing file is an empty queue
+func readSuggestionsFile(path string) ([]*TranslationSuggestion, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", path, err)
+	}
+
+	var entries []*TranslationSuggestion
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+	return entries, nil
+}
+
+// suggestionKey identifies the suggestion of a name, names differing only in spacing or case share one
+func suggestionKey(lang string, name string) string {
+	return lang + "\x00" + NormalizeName(name, false)
+}
+
+// addItemCode adds an item code to the sample codes of a suggestion and reports whether it was new
+func addItemCode(entry *TranslationSuggestion, itemCode string) bool {
+	if itemCode == "" || len(entry.ItemCodes) >= maxSampleCodes {
+		return false
+	}
+	for _, existing := range entry.ItemCodes {
+		if existing == itemCode {
+			return false
+		}
+	}
+	entry.ItemCodes = append(entry.ItemCodes, itemCode)
+	return true
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useSuggestionQueue loads a cn dictionary from a temporary directory and replaces the review queue with entries
+// The queue is restored when the test ends
+func useSuggestionQueue(t *testing.T, entries ...*TranslationSuggestion) {
+	useTranslationFiles(t, map[string]string{directTranslationsFile("cn"): `{"Somun": "螺母"}`})
+
+	suggestions.mutex.Lock()
+	previousQueue := suggestionQueue{entries: suggestions.entries, index: suggestions.index, nextID: suggestions.nextID,
+		attempted: suggestions.attempted, requests: suggestions.requests, dirty: suggestions.dirty}
+	suggestions.entries = entries
+	suggestions.index = make(map[string]*TranslationSuggestion)
+	for _, entry := range entries {
+		suggestions.index[suggestionKey(entry.Language, entry.Source)] = entry
+	}
+	suggestions.nextID = len(entries) + 1
+	suggestions.attempted = make(map[string]time.Time)
+	suggestions.requests = make(chan suggestionRequest, suggestionQueueSize)
+	suggestions.dirty = false
+	suggestions.mutex.Unlock()
+
+	t.Cleanup(func() {
+		suggestions.mutex.Lock()
+		suggestions.entries, suggestions.index, suggestions.nextID = previousQueue.entries, previousQueue.index, previousQueue.nextID
+		suggestions.attempted, suggestions.requests, suggestions.dirty = previousQueue.attempted, previousQueue.requests, previousQueue.dirty
+		suggestions.mutex.Unlock()
+	})
+}
+
+func TestRequestSuggestionsDefersSave(t *testing.T) {
+	useSuggestionQueue(t, &TranslationSuggestion{ID: 1, Language: "cn", Source: "Conta", Target: "密封垫", ItemCodes: []string{"A1"}, Status: SuggestionPending})
+
+	requestSuggestions("cn", []UntranslatedItem{{Code: "A2", Name: "CONTA"}, {Code: "B1", Name: "Kapak"}})
+
+	if _, err := os.Stat(suggestionsFile()); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("suggestions file written on the request path: %v", err)
+	}
+	if codes := GetTranslationSuggestions("cn", "")[0].ItemCodes; len(codes) != 2 || codes[1] != "A2" {
+		t.Errorf("item codes = %v, want [A1 A2]", codes)
+	}
+	if len(suggestions.requests) != 1 {
+		t.Errorf("%d names queued for the translators, want 1", len(suggestions.requests))
+	}
+
+	suggestions.flush()
+	data, err := os.ReadFile(suggestionsFile())
+	if err != nil || !strings.Contains(string(data), "A2") {
+		t.Errorf("suggestions file after flush = %s, %v, want the new item code", data, err)
+	}
+}
+
+func TestApproveTranslationSuggestion(t *testing.T) {
+	useSuggestionQueue(t,
+		&TranslationSuggestion{ID: 1, Language: "cn", Source: "Conta", Target: "密封", Status: SuggestionPending},
+		&TranslationSuggestion{ID: 2, Language: "cn", Source: "Kapak", Target: " ", Status: SuggestionPending},
+	)
+
+	approved, err := ApproveTranslationSuggestion(1, "密封垫", "li.wei")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if approved.Status != SuggestionApproved || approved.Target != "密封垫" || approved.ReviewedBy != "li.wei" {
+		t.Errorf("approved = %+v", approved)
+	}
+	if got := MatchTranslation("cn", "Conta", "").Text; got != "密封垫" {
+		t.Errorf("Conta = %q after approval, want 密封垫", got)
+	}
+	if data, err := os.ReadFile(filepath.Clean(suggestionsFile())); err != nil || !strings.Contains(string(data), SuggestionApproved) {
+		t.Errorf("suggestions file = %s, %v, want the approval", data, err)
+	}
+	if _, err := ApproveTranslationSuggestion(1, "", "li.wei"); !errors.Is(err, ErrSuggestionReviewed) {
+		t.Errorf("second approval error = %v, want ErrSuggestionReviewed", err)
+	}
+
+	// A rejected dictionary write puts the suggestion back in the queue
+	if _, err := ApproveTranslationSuggestion(2, "", "li.wei"); !errors.Is(err, ErrInvalidTranslation) {
+		t.Errorf("approval with an empty target error = %v, want ErrInvalidTranslation", err)
+	}
+	if pending := GetTranslationSuggestions("cn", SuggestionPending); len(pending) != 1 || pending[0].ID != 2 || pending[0].ReviewedBy != "" {
+		t.Errorf("pending suggestions = %+v, want suggestion 2 unreviewed", pending)
+	}
+}
@@ -0,0 +1,431 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults of the usage report
+const (
+	DefaultUsageDays = 30
+	DefaultUsageTop  = 20
+)
+
+// usageDayFormat names the daily usage buckets, days are UTC
+const usageDayFormat = "2006-01-02"
+
+// usageDay holds the lookups of one day by language: hits by entry and misses by name
+// Entries are keyed by provenance and name ("direct|Burç", "prefix:8010|Burç"), item code ("code-override|360004") or rule ("rule:tube")
+type usageDay struct {
+	Hits   map[string]map[string]int `json:"hits"`
+	Misses map[string]map[string]int `json:"misses"`
+}
+
+// translationUsage holds the daily buckets, nil days means tracking is off
+type translationUsage struct {
+	mutex     sync.Mutex
+	days      map[string]*usageDay
+	retention int
+	dirty     bool
+}
+
+var usage translationUsage
+
+// TranslationUsageEntry is a dictionary entry with the number of lookups it answered
+type TranslationUsageEntry struct {
+	Scope  string `json:"scope"`
+	Key    string `json:"key,omitempty"`
+	Source string `json:"source,omitempty"`
+	Target string `json:"target"`
+	Hits   int    `json:"hits"`
+}
+
+// TranslationRuleUsage is a pattern rule with the number of lookups it answered
+type TranslationRuleUsage struct {
+	Rule string `json:"rule"`
+	Hits int    `json:"hits"`
+}
+
+// TranslationMiss is a name without a dictionary entry with the number of lookups
+type TranslationMiss struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// TranslationUsageReport summarizes the lookups of a language over the last days
+// Sources counts the hits by translation source, profile hits are counted as "profile"
+type TranslationUsageReport struct {
+	Language     string                  `json:"language"`
+	Tracking     bool                    `json:"tracking"`
+	Days         int                     `json:"days"`
+	From         string                  `json:"from"`
+	TrackedSince string                  `json:"tracked-since,omitempty"`
+	Lookups      int                     `json:"lookups"`
+	Misses       int                     `json:"misses"`
+	Sources      map[string]int          `json:"sources"`
+	TopEntries   []TranslationUsageEntry `json:"top-entries"`
+	Rules        []TranslationRuleUsage  `json:"rules"`
+	Unused       []TranslationUsageEntry `json:"unused"`
+	TopMisses    []TranslationMiss       `json:"top-misses"`
+}
+
+// usageFile stores the daily usage buckets, it is not a dictionary and is not watched
+func usageFile() string {
+	return filepath.Join(translationDir, "usage.json")
+}
+
+// StartTranslationUsage loads the usage statistics and saves them every flushInterval, keeping retentionDays days
+// A zero interval disables tracking
+func StartTranslationUsage(flushInterval time.Duration, retentionDays int) (stop func(), err error) {
+	if flushInterval <= 0 {
+		return func() {}, nil
+	}
+	if retentionDays <= 0 {
+		return nil, fmt.Errorf("usage retention must be at least one day")
+	}
+
+	days, err := readUsageFile(usageFile())
+	if err != nil {
+		return nil, err
+	}
+
+	usage.mutex.Lock()
+	usage.days = days
+	usage.retention = retentionDays
+	usage.mutex.Unlock()
+
+	done := make(chan struct{})
+	var once sync.Once
+
+	go func() {
+		ticker := time.NewTicker(flushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				usage.save()
+			}
+		}
+	}()
+
+	return func() {
+		once.Do(func() {
+			close(done)
+			usage.save()
+		})
+	}, nil
+}
+
+// UsageRetentionDays returns the number of days kept by usage tracking, 0 when tracking is off
+func UsageRetentionDays() int {
+	usage.mutex.Lock()
+	defer usage.mutex.Unlock()
+
+	if usage.days == nil {
+		return 0
+	}
+	return usage.retention
+}
+
+// readUsageFile reads the daily usage buckets, a missing file is no usage yet
+func readUsageFile(path string) (map[string]*usageDay, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return make(map[string]*usageDay), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", path, err)
+	}
+
+	var days map[string]*usageDay
+	if err := json.Unmarshal(data, &days); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+	if days == nil {
+		days = make(map[string]*usageDay)
+	}
+	return days, nil
+}
+
+// save drops the days past the retention and writes the buckets if they changed
+func (u *translationUsage) save() {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	if !u.dirty || u.days == nil {
+		return
+	}
+
+	oldest := time.Now().UTC().AddDate(0, 0, -(u.retention - 1)).Format(usageDayFormat)
+	for day := range u.days {
+		if day < oldest {
+			delete(u.days, day)
+		}
+	}
+
+	data, err := marshalDictionary(u.days)
+	if err == nil {
+		err = writeFileAtomic(usageFile(), data)
+	}
+	if err != nil {
+		log.Printf("Error saving translation usage: %v", err)
+		return
+	}
+	u.dirty = false
+}
+
+// usageMatcher records the lookups of match in the usage statistics of lang
+// Each name of an item counts once per BOM, parents repeat on every line of their children
+func usageMatcher(lang string, match func(turkishText string, itemCode string) TranslationMatch) func(turkishText string, itemCode string) TranslationMatch {
+	seen := make(map[string]bool)
+	return func(turkishText string, itemCode string) TranslationMatch {
+		result := match(turkishText, itemCode)
+		if key := itemCode + "\x00" + turkishText; !seen[key] {
+			seen[key] = true
+			recordUsage(lang, turkishText, itemCode, result)
+		}
+		return result
+	}
+}
+
+// recordUsage counts a lookup in today's bucket, untranslated and composed names are misses
+func recordUsage(lang string, turkishText string, itemCode string, match TranslationMatch) {
+	usage.mutex.Lock()
+	defer usage.mutex.Unlock()
+
+	if usage.days == nil {
+		return
+	}
+
+	today := time.Now().UTC().Format(usageDayFormat)
+	day, exists := usage.days[today]
+	if !exists {
+		day = &usageDay{Hits: make(map[string]map[string]int), Misses: make(map[string]map[string]int)}
+		usage.days[today] = day
+	}
+
+	if match.Source == "" || match.Source == SourceComposed {
+		if day.Misses[lang] == nil {
+			day.Misses[lang] = make(map[string]int)
+		}
+		day.Misses[lang][turkishText]++
+	} else {
+		if day.Hits[lang] == nil {
+			day.Hits[lang] = make(map[string]int)
+		}
+		day.Hits[lang][usageKey(match, turkishText, itemCode)]++
+	}
+	usage.dirty = true
+}
+
+// usageKey names the entry that answered a lookup
+func usageKey(match TranslationMatch, turkishText string, itemCode string) string {
+	switch match.Source {
+	case SourceCodeOverride:
+		return match.Provenance() + "|" + strings.TrimSpace(itemCode)
+	case SourceRule:
+		return match.Provenance()
+	}
+	return match.Provenance() + "|" + turkishText
+}
+
+// usageEntryKey identifies a dictionary entry while aggregating hits
+type usageEntryKey struct {
+	scope  string
+	key    string
+	source string
+}
+
+// GetTranslationUsage reports the lookups of lang over the last days (today included):
+// the most used entries, the hits of every rule, the entries never used and the most frequent misses
+func GetTranslationUsage(lang string, days int, top int) (TranslationUsageReport, error) {
+	// Load translations if not already loaded
+	if err := LoadTranslations(); err != nil {
+		return TranslationUsageReport{}, fmt.Errorf("error loading translations: %v", err)
+	}
+	if err := checkLanguages([]string{lang}); err != nil {
+		return TranslationUsageReport{}, err
+	}
+	if days <= 0 {
+		days = DefaultUsageDays
+	}
+	if top <= 0 {
+		top = DefaultUsageTop
+	}
+
+	report := TranslationUsageReport{
+		Language:   lang,
+		Days:       days,
+		From:       time.Now().UTC().AddDate(0, 0, -(days - 1)).Format(usageDayFormat),
+		Sources:    make(map[string]int),
+		TopEntries: []TranslationUsageEntry{},
+		Rules:      []TranslationRuleUsage{},
+		Unused:     []TranslationUsageEntry{},
+		TopMisses:  []TranslationMiss{},
+	}
+
+	// Sum the buckets of the window
+	hits := make(map[string]int)
+	misses := make(map[string]int)
+	usage.mutex.Lock()
+	report.Tracking = usage.days != nil
+	for day, bucket := range usage.days {
+		if report.TrackedSince == "" || day < report.TrackedSince {
+			report.TrackedSince = day
+		}
+		if day < report.From {
+			continue
+		}
+		for key, count := range bucket.Hits[lang] {
+			hits[key] += count
+		}
+		for name, count := range bucket.Misses[lang] {
+			misses[name] += count
+		}
+	}
+	usage.mutex.Unlock()
+
+	translationMutex.RLock()
+	defer translationMutex.RUnlock()
+
+	dict := dictionaries[lang]
+	targets, index := usageEntries(dict)
+
+	// Attribute the hits to the entries the way lookups find them: exact key first, then normalized
+	entryHits := make(map[usageEntryKey]int)
+	ruleHits := make(map[string]int)
+	for key, count := range hits {
+		report.Lookups += count
+		provenance, name, _ := strings.Cut(key, "|")
+		kind, detail, _ := strings.Cut(provenance, ":")
+		switch kind {
+		case "profile":
+			report.Sources["profile"] += count
+			continue
+		case SourceRule:
+			report.Sources[SourceRule] += count
+			ruleHits[detail] += count
+			continue
+		}
+		report.Sources[kind] += count
+
+		var entry usageEntryKey
+		switch kind {
+		case SourceCodeOverride:
+			entry = usageEntryKey{scope: ScopeCode, key: name}
+		case SourcePrefix:
+			entry = usageEntryKey{scope: ScopePrefix, key: detail, source: name}
+		default:
+			entry = usageEntryKey{scope: ScopeDirect, source: name}
+		}
+		if _, exists := targets[entry]; !exists && entry.scope != ScopeCode {
+			normalized := entry
+			normalized.source = NormalizeName(entry.source, true)
+			if entry, exists = index[normalized]; !exists {
+				continue
+			}
+		}
+		entryHits[entry] += count
+	}
+
+	for name, count := range misses {
+		report.Lookups += count
+		report.Misses += count
+		report.TopMisses = append(report.TopMisses, TranslationMiss{Name: name, Count: count})
+	}
+	sort.Slice(report.TopMisses, func(i, j int) bool {
+		if report.TopMisses[i].Count != report.TopMisses[j].Count {
+			return report.TopMisses[i].Count > report.TopMisses[j].Count
+		}
+		return report.TopMisses[i].Name < report.TopMisses[j].Name
+	})
+	if len(report.TopMisses) > top {
+		report.TopMisses = report.TopMisses[:top]
+	}
+
+	for entry, target := range targets {
+		usageEntry := TranslationUsageEntry{Scope: entry.scope, Key: entry.key, Source: entry.source, Target: target, Hits: entryHits[entry]}
+		if usageEntry.Hits == 0 {
+			report.Unused = append(report.Unused, usageEntry)
+		} else {
+			report.TopEntries = append(report.TopEntries, usageEntry)
+		}
+	}
+	sortUsageEntries(report.Unused)
+	sortUsageEntries(report.TopEntries)
+	if len(report.TopEntries) > top {
+		report.TopEntries = report.TopEntries[:top]
+	}
+
+	if dict != nil {
+		for _, rule := range dict.rules {
+			report.Rules = append(report.Rules, TranslationRuleUsage{Rule: rule.ID, Hits: ruleHits[rule.ID]})
+		}
+	}
+	sort.SliceStable(report.Rules, func(i, j int) bool {
+		return report.Rules[i].Hits > report.Rules[j].Hits
+	})
+
+	return report, nil
+}
+
+// usageEntries returns the direct, prefix fallback and item code entries of a dictionary with their targets,
+// and the direct and prefix entries indexed by normalized source; the caller must hold translationMutex
+func usageEntries(dict *dictionary) (map[usageEntryKey]string, map[usageEntryKey]usageEntryKey) {
+	targets := make(map[usageEntryKey]string)
+	index := make(map[usageEntryKey]usageEntryKey)
+	if dict == nil {
+		return targets, index
+	}
+
+	addIndexed := func(entry usageEntryKey) {
+		normalized := entry
+		normalized.source = NormalizeName(entry.source, true)
+		if existing, exists := index[normalized]; !exists || entry.source < existing.source {
+			index[normalized] = entry
+		}
+	}
+	for source, target := range dict.translations {
+		entry := usageEntryKey{scope: ScopeDirect, source: source}
+		targets[entry] = target
+		addIndexed(entry)
+	}
+	for prefix, prefixTranslations := range dict.fallbackTranslations {
+		for source, target := range prefixTranslations {
+			entry := usageEntryKey{scope: ScopePrefix, key: prefix, source: source}
+			targets[entry] = target
+			addIndexed(entry)
+		}
+	}
+	for itemCode, target := range dict.codeTranslations {
+		targets[usageEntryKey{scope: ScopeCode, key: itemCode}] = target
+	}
+	return targets, index
+}
+
+// sortUsageEntries orders entries by hits, then by scope, key and source
+func sortUsageEntries(entries []TranslationUsageEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Hits != entries[j].Hits {
+			return entries[i].Hits > entries[j].Hits
+		}
+		if entries[i].Scope != entries[j].Scope {
+			return entries[i].Scope < entries[j].Scope
+		}
+		if entries[i].Key != entries[j].Key {
+			return entries[i].Key < entries[j].Key
+		}
+		return entries[i].Source < entries[j].Source
+	})
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// ValueFieldUnit is the value field of units of measure
+const ValueFieldUnit = "unit"
+
+var valueFieldPattern = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// valueTranslationsFile returns the enumerated value mapping of a language, e.g. translate/values-tr-to-cn.json
+// The file maps each field to its Turkish ERP values and their translations: {"unit": {"Adet": "个"}}
+func valueTranslationsFile(lang string) string {
+	return filepath.Join(translationDir, "values-tr-to-"+lang+".json")
+}
+
+// readValuesFile reads and validates a value mapping and indexes every field by normalized Turkish value
+// Values that only differ in case, spacing or diacritics ("ADET", "Adet") must have the same translation
+func readValuesFile(path string) (entries map[string]map[string]string, index map[string]map[string]string, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading %s: %v", path, err)
+	}
+
+	entries, err = parseFallbackTranslations(path, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	index = make(map[string]map[string]string)
+	for field, values := range entries {
+		if !valueFieldPattern.MatchString(field) {
+			return nil, nil, fmt.Errorf("invalid field in %s: %q (lower case letters, digits and '-')", path, field)
+		}
+
+		sources := make([]string, 0, len(values))
+		for source := range values {
+			sources = append(sources, source)
+		}
+		sort.Strings(sources)
+
+		index[field] = make(map[string]string)
+		seen := make(map[string]string)
+		for _, source := range sources {
+			key := NormalizeName(source, true)
+			if key == "" {
+				return nil, nil, fmt.Errorf("invalid entry in %s: empty %s value %q", path, field, source)
+			}
+			if other, exists := seen[key]; exists && index[field][key] != values[source] {
+				return nil, nil, fmt.Errorf("invalid entry in %s: %s values %q and %q have different translations", path, field, other, source)
+			}
+			seen[key] = source
+			index[field][key] = values[source]
+		}
+	}
+	return entries, index, nil
+}
+
+// translateValue returns the translation of an ERP value of a field, the caller must hold translationMutex
+func (d *dictionary) translateValue(field string, value string) (string, bool) {
+	translated, exists := d.values[field][NormalizeName(value, true)]
+	return translated, exists
+}
+
+// TranslateValue returns the translation of an enumerated ERP value into lang, e.g. the unit "Adet" into "个"
+// Values without a mapping are returned unchanged with ok false
+func TranslateValue(lang string, field string, value string) (string, bool) {
+	translationMutex.RLock()
+	defer translationMutex.RUnlock()
+
+	dict, exists := dictionaries[lang]
+	if !exists {
+		return value, false
+	}
+	if translated, ok := dict.translateValue(field, value); ok {
+		return translated, true
+	}
+	return value, false
+}
+
+// SameValue reports whether an ERP value and a value of another system in lang mean the same, e.g. "Adet" and "个"
+// Both sides are mapped when they are Turkish values, then compared ignoring case, spaces and full-width forms
+func SameValue(lang string, field string, erpValue string, otherValue string) bool {
+	translatedERP, _ := TranslateValue(lang, field, erpValue)
+	translatedOther, _ := TranslateValue(lang, field, otherValue)
+	return normalizeTarget(translatedERP) == normalizeTarget(translatedOther)
+}
+
+// GetValueTranslations returns the value mapping of a language by field
+func GetValueTranslations(lang string) (map[string]map[string]string, error) {
+	// Load translations if not already loaded
+	if err := LoadTranslations(); err != nil {
+		return nil, fmt.Errorf("error loading translations: %v", err)
+	}
+	if err := checkLanguages([]string{lang}); err != nil {
+		return nil, err
+	}
+
+	translationMutex.RLock()
+	defer translationMutex.RUnlock()
+
+	values := make(map[string]map[string]string)
+	for field, entries := range dictionaries[lang].valueEntries {
+		values[field] = make(map[string]string)
+		for source, target := range entries {
+			values[field][source] = target
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"resco/db"
+	"strings"
+)
+
+// itemUnitColumn is the STOK00 column holding the unit of an item, units are not read when empty
+var itemUnitColumn string
+
+// maxUnitQueryCodes keeps unit queries below the SQL Server limit of 2100 parameters
+const maxUnitQueryCodes = 1000
+
+// SetItemUnitColumn configures the STOK00 column holding the unit of measure, e.g. "BIRIM"
+// An empty column disables units in BOM responses and product checks
+func SetItemUnitColumn(column string) error {
+	if column != "" && !columnPattern.MatchString(column) {
+		return fmt.Errorf("invalid column name: %q", column)
+	}
+	itemUnitColumn = column
+	return nil
+}
+
+// getItemUnits returns the unit of each item code with a unit
+func getItemUnits(ctx context.Context, conn *db.Connection, codes []string) (map[string]string, error) {
+	units := make(map[string]string)
+	if itemUnitColumn == "" {
+		return units, nil
+	}
+
+	for start := 0; start < len(codes); start += maxUnitQueryCodes {
+		end := start + maxUnitQueryCodes
+		if end > len(codes) {
+			end = len(codes)
+		}
+
+		placeholders := make([]string, 0, end-start)
+		args := make([]interface{}, 0, end-start)
+		for i, code := range codes[start:end] {
+			param := fmt.Sprintf("c%d", i)
+			placeholders = append(placeholders, "@"+param)
+			args = append(args, sql.Named(param, code))
+		}
+
+		query := fmt.Sprintf("SELECT TRIM(KOD), TRIM([%s]) FROM %s WHERE KOD IN (%s) AND [%s] IS NOT NULL",
+			itemUnitColumn, conn.Table("STOK00"), strings.Join(placeholders, ", "), itemUnitColumn)
+		if err := scanItemUnits(ctx, conn, query, args, units); err != nil {
+			return nil, err
+		}
+	}
+	return units, nil
+}
+
+func scanItemUnits(ctx context.Context, conn *db.Connection, query string, args []interface{}, units map[string]string) error {
+	rows, err := conn.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error executing query: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var code, unit string
+		if err := rows.Scan(&code, &unit); err != nil {
+			return fmt.Errorf("error scanning row: %v", err)
+		}
+		if unit != "" {
+			units[code] = unit
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("error iterating rows: %v", err)
+	}
+	return nil
+}
+
+// attachUnits sets the unit of the child of every BOM line, the unit of child-quantity
+func attachUnits(ctx context.Context, conn *db.Connection, results []BOMResult) error {
+	if itemUnitColumn == "" {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var codes []string
+	for _, result := range results {
+		if result.BOMRecKaynakCode != "" && !seen[result.BOMRecKaynakCode] {
+			seen[result.BOMRecKaynakCode] = true
+			codes = append(codes, result.BOMRecKaynakCode)
+		}
+	}
+
+	units, err := getItemUnits(ctx, conn, codes)
+	if err != nil {
+		return err
+	}
+	for i := range results {
+		if unit, exists := units[results[i].BOMRecKaynakCode]; exists {
+			unit := unit
+			results[i].ChildUnit = &unit
+		}
+	}
+	return nil
+}